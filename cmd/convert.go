@@ -7,14 +7,22 @@ package cmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gaurav-prasanna/pagepipe/core"
+	"github.com/gaurav-prasanna/pagepipe/core/bundle"
 	"github.com/gaurav-prasanna/pagepipe/core/extract"
 	"github.com/gaurav-prasanna/pagepipe/core/fetch"
+	"github.com/gaurav-prasanna/pagepipe/core/httpclient"
 	"github.com/gaurav-prasanna/pagepipe/core/normalize"
 	"github.com/gaurav-prasanna/pagepipe/core/output"
 	"github.com/gaurav-prasanna/pagepipe/core/render"
@@ -22,6 +30,23 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Values accepted by --bundle_order.
+const (
+	bundleOrderDiscovery = "discovery"
+	bundleOrderPath      = "path"
+)
+
+// Values accepted by --render_js.
+const (
+	renderJSNever  = "never"
+	renderJSAuto   = "auto"
+	renderJSAlways = "always"
+)
+
+// progress receives status messages. It is stderr when --stdout puts the
+// output itself on stdout.
+var progress io.Writer = os.Stdout
+
 // Flag variables.
 var (
 	flagOnly       bool
@@ -30,16 +55,77 @@ var (
 	flagMarkdown   bool
 	flagJSON       bool
 	flagEmbeddings bool
+	flagEPUB       bool
+	flagHTML       bool
+	flagHTMLCSS    string
+	flagText       bool
+	flagTextWidth  int
+	flagPDFFont    string
 	flagModel      string
 	flagChunkSize  int
 	flagOutputDir  string
+	flagMaxBodyMB  int
+
+	// PDF layout flags.
+	flagPDFPageSize    string
+	flagPDFOrientation string
+	flagPDFMargins     string
+	flagPDFFontSize    float64
+	flagPDFPageNumbers bool
+	flagPDFHeader      bool
+	flagPDFTOC         bool
+	flagPDFOutline     bool
+
+	// Code block flags (--pdf, --html, --epub).
+	flagCodeStyle       string
+	flagCodeLineNumbers bool
+
+	// JavaScript rendering flags.
+	flagRenderJS   string
+	flagWaitFor    string
+	flagChromePath string
+	flagRenderWait time.Duration
+
+	// Authentication flags.
+	flagCookies     string
+	flagHeaders     []string
+	flagBasicAuth   []string
+	flagBearerToken []string
+
+	// Network flags.
+	flagProxy           string
+	flagCACert          string
+	flagClientCert      string
+	flagClientKey       string
+	flagInsecureHosts   []string
+	flagMaxConnsPerHost int
+	flagUserAgent       string
+	flagIgnoreRobots    bool
+
+	// Overwrite policy flags (mutually exclusive).
+	flagOverwrite        bool
+	flagSkipExisting     bool
+	flagSuffixOnConflict bool
+
+	// Crawl output flags.
+	flagIndexMD bool
+	flagArchive string
+	flagStdout  bool
+
+	// Bundle flags.
+	flagBundle      bool
+	flagBundleOrder string
+
+	// Crawl deduplication flags.
+	flagStripParams     []string
+	flagSimHashDistance int
 )
 
 var convertCmd = &cobra.Command{
 	Use:   "convert <url>",
 	Short: "Convert a URL to the specified output format",
 	Long: `Convert fetches a webpage, extracts main content, normalizes it to Markdown,
-and converts it to the specified output format (PDF, Markdown, JSON, or Embeddings).
+and converts it to the specified output format (PDF, Markdown, JSON, HTML, plain text, EPUB, or Embeddings).
 
 Examples:
   pagepipe convert https://example.com --markdown
@@ -62,6 +148,30 @@ func init() {
 	convertCmd.Flags().BoolVar(&flagMarkdown, "markdown", false, "Output Markdown")
 	convertCmd.Flags().BoolVar(&flagJSON, "json", false, "Output structured JSON")
 	convertCmd.Flags().BoolVar(&flagEmbeddings, "embeddings", false, "Output embeddings")
+	convertCmd.Flags().BoolVar(&flagEPUB, "epub", false, "Output EPUB 3")
+	convertCmd.Flags().BoolVar(&flagHTML, "html", false, "Output standalone, sanitized HTML5")
+	convertCmd.Flags().BoolVar(&flagText, "text", false, "Output plain text")
+
+	// Plain-text-specific flags.
+	convertCmd.Flags().IntVar(&flagTextWidth, "text_width", render.DefaultTextWidth, "Line width of --text output (0 disables wrapping)")
+
+	// PDF-specific flags.
+	convertCmd.Flags().StringVar(&flagPDFFont, "pdf_font", "", "TrueType (.ttf) font for PDF body text instead of the bundled DejaVu Sans; Name-Bold.ttf etc. next to it are used for bold and italic")
+	convertCmd.Flags().StringVar(&flagPDFPageSize, "pdf_page_size", "A4", "PDF page size: A3, A4, A5, Letter, Legal or WIDTHxHEIGHT in mm")
+	convertCmd.Flags().StringVar(&flagPDFOrientation, "pdf_orientation", "portrait", "PDF page orientation: portrait or landscape")
+	convertCmd.Flags().StringVar(&flagPDFMargins, "pdf_margins", "10,10,15,10", "PDF page margins in mm: one value, or top,right,bottom,left")
+	convertCmd.Flags().Float64Var(&flagPDFFontSize, "pdf_font_size", 10, "PDF body text size in points; headings and code scale with it")
+	convertCmd.Flags().BoolVar(&flagPDFPageNumbers, "pdf_page_numbers", false, `Add a "Page N of M" footer to PDF pages`)
+	convertCmd.Flags().BoolVar(&flagPDFHeader, "pdf_header", false, "Repeat the page title at the top of every PDF page after the first")
+	convertCmd.Flags().BoolVar(&flagPDFTOC, "pdf_toc", false, "Add a table of contents with page numbers after the PDF title")
+	convertCmd.Flags().BoolVar(&flagPDFOutline, "pdf_outline", true, "Add PDF bookmarks built from headings (--pdf_outline=false to omit)")
+
+	// Code block flags.
+	convertCmd.Flags().StringVar(&flagCodeStyle, "code_style", render.DefaultCodeStyle, `Syntax highlighting style for code blocks in --pdf, --html and --epub output ("none" for plain)`)
+	convertCmd.Flags().BoolVar(&flagCodeLineNumbers, "code_line_numbers", false, "Number the lines of code blocks in --pdf, --html and --epub output")
+
+	// HTML-specific flags.
+	convertCmd.Flags().StringVar(&flagHTMLCSS, "html_css", "", `CSS file to embed in --html output instead of the default stylesheet ("none" for unstyled)`)
 
 	// Embedding-specific flags.
 	convertCmd.Flags().StringVar(&flagModel, "model", "", "Embedding model (required with --embeddings)")
@@ -69,13 +179,55 @@ func init() {
 
 	// Output directory.
 	convertCmd.Flags().StringVar(&flagOutputDir, "output_dir", "", "Output directory (default: current directory)")
+
+	// Overwrite policy flags (mutually exclusive).
+	convertCmd.Flags().BoolVar(&flagOverwrite, "overwrite", false, "Replace existing output files (default)")
+	convertCmd.Flags().BoolVar(&flagSkipExisting, "skip_existing", false, "Leave existing output files untouched")
+	convertCmd.Flags().BoolVar(&flagSuffixOnConflict, "suffix_on_conflict", false, "Write to name-1.ext, name-2.ext, ... when the output file exists")
+
+	// Fetch limits.
+	convertCmd.Flags().IntVar(&flagMaxBodyMB, "max_body_mb", int(fetch.DefaultMaxBodySize>>20), "Maximum response body size in MiB")
+
+	// JavaScript rendering flags.
+	convertCmd.Flags().StringVar(&flagRenderJS, "render_js", renderJSNever, "Render pages in headless Chromium: never, auto (when static HTML looks empty) or always")
+	convertCmd.Flags().StringVar(&flagWaitFor, "wait_for", "", "CSS selector to wait for before capturing a rendered page (default: network idle)")
+	convertCmd.Flags().StringVar(&flagChromePath, "chrome_path", "", "Path to the Chromium/Chrome binary (default: search PATH)")
+	convertCmd.Flags().DurationVar(&flagRenderWait, "render_wait", fetch.DefaultRenderWait, "Maximum time to wait for a rendered page to settle")
+
+	// Authentication flags. Values may be prefixed with "host=" to scope
+	// them to another host; by default they apply to the URL's host only.
+	convertCmd.Flags().StringVar(&flagCookies, "cookies", "", "Netscape cookies.txt file to send cookies from")
+	convertCmd.Flags().StringArrayVar(&flagHeaders, "header", nil, `Extra request header "Name: value" (repeatable; prefix with "host=" to scope)`)
+	convertCmd.Flags().StringArrayVar(&flagBasicAuth, "basic_auth", nil, `HTTP basic credentials "user:password" (repeatable; prefix with "host=" to scope)`)
+	convertCmd.Flags().StringArrayVar(&flagBearerToken, "bearer_token", nil, `Bearer token (repeatable; prefix with "host=" to scope)`)
+
+	// Network flags, shared by page fetches, sitemap discovery and embeddings.
+	convertCmd.Flags().StringVar(&flagProxy, "proxy", "", "Proxy URL for all requests (default: HTTP_PROXY/HTTPS_PROXY environment)")
+	convertCmd.Flags().StringVar(&flagCACert, "ca_cert", "", "PEM bundle of extra trusted CA certificates")
+	convertCmd.Flags().StringVar(&flagClientCert, "client_cert", "", "PEM client certificate for mutual TLS")
+	convertCmd.Flags().StringVar(&flagClientKey, "client_key", "", "PEM private key for --client_cert")
+	convertCmd.Flags().StringSliceVar(&flagInsecureHosts, "insecure_host", nil, "Skip TLS verification for these hosts only (test hosts with self-signed certificates)")
+	convertCmd.Flags().IntVar(&flagMaxConnsPerHost, "max_conns_per_host", 0, "Limit concurrent connections per host (0 = unlimited)")
+	convertCmd.Flags().StringVar(&flagUserAgent, "user_agent", httpclient.DefaultUserAgent, "User-Agent for every request; its product token also selects the robots.txt group")
+	convertCmd.Flags().BoolVar(&flagIgnoreRobots, "ignore_robots", false, "Do not apply robots.txt rules in --all mode")
+
+	// Crawl output flags (--all mode).
+	convertCmd.Flags().BoolVar(&flagStdout, "stdout", false, "Write output to stdout instead of files (--all: one NDJSON record per page)")
+	convertCmd.Flags().StringVar(&flagArchive, "archive", "", "Write all outputs into a single .zip, .tar.gz or .tgz file instead of a directory")
+	convertCmd.Flags().BoolVar(&flagBundle, "bundle", false, "Combine all pages into one document with a table of contents (--markdown, --pdf, --html, --text or --epub)")
+	convertCmd.Flags().StringVar(&flagBundleOrder, "bundle_order", bundleOrderDiscovery, "Page order in a bundle: discovery (sitemap or crawl order) or path (URL hierarchy)")
+	convertCmd.Flags().BoolVar(&flagIndexMD, "index_md", false, "Also write index.md, a table of contents linking every output file")
+
+	// Deduplication flags (--all mode).
+	convertCmd.Flags().StringSliceVar(&flagStripParams, "strip_params", nil, "Extra query parameters to ignore when deduplicating URLs (trailing * matches a prefix)")
+	convertCmd.Flags().IntVar(&flagSimHashDistance, "simhash_distance", crawl.DefaultSimHashDistance, "Max SimHash distance for near-duplicate pages (negative disables)")
 }
 
 func runConvert(cmd *cobra.Command, args []string) error {
 	rawURL := args[0]
 
 	// --- Validate flags ---
-	if err := validateFlags(); err != nil {
+	if err := validateFlags(cmd); err != nil {
 		return err
 	}
 
@@ -85,28 +237,72 @@ func runConvert(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid URL: %s (must include scheme, e.g. https://example.com)", rawURL)
 	}
 
+	// One transport (proxy, TLS, connection pool) shared by every client.
+	transport, err := httpclient.NewTransport(httpConfig())
+	if err != nil {
+		return err
+	}
+
 	// Select renderer.
-	renderer, err := selectRenderer()
+	renderer, err := selectRenderer(transport)
+	if err != nil {
+		return err
+	}
+
+	auth, err := buildAuth(parsed.Host)
 	if err != nil {
 		return err
 	}
 
 	// Initialize pipeline components.
-	fetcher := fetch.New()
+	fetcher, closeFetcher := buildFetcher(transport, auth)
+	defer closeFetcher()
 	extractor := extract.New()
 	normalizer := normalize.New()
 
-	writer, err := output.New(flagOutputDir)
-	if err != nil {
+	var writer *output.Writer
+	if flagStdout {
+		progress = os.Stderr
+	} else if writer, err = buildWriter(); err != nil {
 		return fmt.Errorf("initializing output writer: %w", err)
 	}
 
-	ctx := context.Background()
+	ctx, signalled, stop := signalContext(context.Background())
+	defer stop()
 
 	if flagAll {
-		return runAll(ctx, rawURL, fetcher, extractor, normalizer, renderer, writer)
+		discover := crawl.Options{
+			Auth:         auth,
+			Transport:    transport,
+			UserAgent:    flagUserAgent,
+			IgnoreRobots: flagIgnoreRobots,
+			OnWarning: func(msg string) {
+				fmt.Fprintf(progress, "  ⚠ Warning: %s\n", msg)
+			},
+		}
+		err = runAll(ctx, rawURL, fetcher, extractor, normalizer, renderer, writer, discover)
+	} else {
+		err = runOnly(ctx, rawURL, fetcher, extractor, normalizer, renderer, writer)
 	}
-	return runOnly(ctx, rawURL, fetcher, extractor, normalizer, renderer, writer)
+
+	// An interrupted run still produces a complete archive of the pages
+	// written so far; one that failed outright leaves nothing behind.
+	if writer == nil {
+		// Nothing to finalize when streaming to stdout.
+	} else if err != nil && signalled() == nil {
+		writer.Abort()
+	} else if cerr := writer.Close(); cerr != nil {
+		err = cerr
+	} else if flagArchive != "" {
+		fmt.Fprintf(progress, "✓ Archive: %s\n", writer.Location())
+	}
+
+	// Whatever failed after a signal failed because of it.
+	if sig := signalled(); sig != nil {
+		cmd.SilenceUsage = true
+		return &interruptedError{sig: sig}
+	}
+	return err
 }
 
 // runOnly processes a single URL through the pipeline.
@@ -119,17 +315,27 @@ func runOnly(
 	renderer core.Renderer,
 	writer *output.Writer,
 ) error {
-	data, meta, err := processURL(ctx, rawURL, fetcher, extractor, normalizer, renderer)
+	pg, err := processURL(ctx, rawURL, fetcher, extractor, normalizer, renderer)
 	if err != nil {
 		return err
 	}
-	_ = meta
 
-	path, err := writer.WriteOnly(rawURL, data, renderer.Extension())
+	if flagStdout {
+		if _, err := os.Stdout.Write(pg.Data); err != nil {
+			return fmt.Errorf("writing to stdout: %w", err)
+		}
+		return nil
+	}
+
+	path, err := writer.WriteOnly(rawURL, pg.Data, renderer.Extension())
+	if errors.Is(err, output.ErrExists) {
+		fmt.Fprintf(progress, "- Skipped: %s already exists\n", path)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(os.Stdout, "✓ Written: %s\n", path)
+	fmt.Fprintf(progress, "✓ Written: %s\n", path)
 	return nil
 }
 
@@ -142,43 +348,285 @@ func runAll(
 	normalizer core.Normalizer,
 	renderer core.Renderer,
 	writer *output.Writer,
+	discover crawl.Options,
 ) error {
-	fmt.Fprintf(os.Stdout, "Discovering pages from %s...\n", rawURL)
+	fmt.Fprintf(progress, "Discovering pages from %s...\n", rawURL)
+
+	urlNormalizer := crawl.NewURLNormalizer(flagStripParams...)
+	discover.Normalizer = urlNormalizer
 
 	// Discover all internal URLs.
-	urls, err := crawl.DiscoverAll(ctx, rawURL, fetcher)
+	urls, err := crawl.DiscoverAll(ctx, rawURL, fetcher, discover)
 	if err != nil {
 		return fmt.Errorf("discovering pages: %w", err)
 	}
 
-	fmt.Fprintf(os.Stdout, "Found %d pages to process\n", len(urls))
+	fmt.Fprintf(progress, "Found %d pages to process\n", len(urls))
+
+	dedup := crawl.NewDeduper(flagSimHashDistance)
+	domain := ""
+	if parsed, err := url.Parse(rawURL); err == nil {
+		domain = parsed.Host
+	}
+
+	// With --stdout, pages are streamed as NDJSON instead of written.
+	// With --bundle, pages are collected and written as one document.
+	var stream *output.Stream
+	var streamErr error
+	var book *bundle.Bundle
+	var siteMeta *core.PageMetadata
+	switch {
+	case flagBundle:
+		book = bundle.New(urlNormalizer.Normalize)
+	case flagStdout:
+		stream = output.NewStream(os.Stdout)
+	case flagIndexMD:
+		writer.Reserve(output.TOCFile)
+	}
+	manifest := &output.Manifest{
+		Source:    rawURL,
+		Format:    renderer.Extension(),
+		StartedAt: time.Now().UTC(),
+	}
 
-	var errCount int
+	var errCount, dupCount, existCount, done int
 	for i, pageURL := range urls {
-		fmt.Fprintf(os.Stdout, "[%d/%d] Processing %s\n", i+1, len(urls), pageURL)
+		// Stop between pages once cancelled; the summary is still printed.
+		if ctx.Err() != nil || streamErr != nil {
+			break
+		}
+		done++
+		fmt.Fprintf(progress, "[%d/%d] Processing %s\n", i+1, len(urls), pageURL)
+
+		entry := output.ManifestEntry{URL: pageURL}
+		start := time.Now()
+		var data []byte // rendered output, streamed with a written record
+		record := func(status string, err error) {
+			entry.Status = status
+			entry.DurationMS = time.Since(start).Milliseconds()
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			manifest.Pages = append(manifest.Pages, entry)
+			if stream != nil {
+				streamErr = stream.Emit(entry, renderer.Extension(), data)
+			}
+		}
 
-		data, _, err := processURL(ctx, pageURL, fetcher, extractor, normalizer, renderer)
+		// A page interrupted before it is written is dropped entirely;
+		// one that has been rendered is always written.
+		pg, err := ingestURL(ctx, pageURL, fetcher, extractor, normalizer)
+		if ctx.Err() != nil {
+			done--
+			break
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "  ✗ Error: %v\n", err)
 			errCount++
+			record(output.StatusFailed, err)
+			continue
+		}
+		entry.FinalURL = pg.Meta.URL
+		entry.Title = pg.Meta.Title
+		if pg.Meta.Fetch != nil {
+			entry.StatusCode = pg.Meta.Fetch.StatusCode
+		}
+		entry.ContentHash = contentHash(pg.Markdown)
+		entry.WordCount = len(strings.Fields(pg.Markdown))
+
+		// Each logical page is written once: key it by its final URL after
+		// redirects, or its declared canonical URL (when on-site), and
+		// compare content hashes.
+		key := urlNormalizer.Normalize(pg.Meta.URL)
+		if c := pg.Meta.CanonicalURL; c != "" && crawl.IsSameDomain(c, domain) {
+			key = urlNormalizer.Normalize(c)
+		}
+		if dupOf, dup := dedup.Add(key, pg.Markdown); dup {
+			fmt.Fprintf(progress, "  - Skipped: duplicate of %s\n", dupOf)
+			dupCount++
+			entry.DuplicateOf = dupOf
+			record(output.StatusDuplicate, nil)
+			continue
+		}
+
+		if book != nil {
+			if siteMeta == nil {
+				siteMeta = &pg.Meta
+			}
+			var aliases []string
+			if c := pg.Meta.CanonicalURL; c != "" && crawl.IsSameDomain(c, domain) {
+				aliases = append(aliases, c)
+			}
+			book.Add(bundle.Page{
+				URL:      pg.Meta.URL,
+				Aliases:  append(aliases, pageURL),
+				Title:    pg.Meta.Title,
+				Markdown: pg.Markdown,
+			})
+			fmt.Fprintf(progress, "  ✓ Added to bundle\n")
+			record(output.StatusWritten, nil)
+			continue
+		}
+
+		if err := renderPage(ctx, pg, renderer); err != nil {
+			if ctx.Err() != nil {
+				done--
+				break
+			}
+			fmt.Fprintf(os.Stderr, "  ✗ Error: %v\n", err)
+			errCount++
+			record(output.StatusFailed, err)
 			continue
 		}
 
-		path, err := writer.WriteAll(pageURL, data, renderer.Extension())
+		if stream != nil {
+			data = pg.Data
+			record(output.StatusWritten, nil)
+			continue
+		}
+
+		path, err := writer.WriteAll(pageURL, pg.Data, renderer.Extension())
+		if errors.Is(err, output.ErrExists) {
+			fmt.Fprintf(progress, "  - Skipped: %s already exists\n", path)
+			existCount++
+			entry.Path, _ = writer.Lookup(pageURL)
+			record(output.StatusExists, nil)
+			continue
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "  ✗ Write error: %v\n", err)
 			errCount++
+			record(output.StatusFailed, err)
 			continue
 		}
-		fmt.Fprintf(os.Stdout, "  ✓ Written: %s\n", path)
+		fmt.Fprintf(progress, "  ✓ Written: %s\n", path)
+		entry.Path, _ = writer.Lookup(pageURL)
+		record(output.StatusWritten, nil)
 	}
 
+	// URLs never reached (or dropped mid-page) after an interruption.
+	for _, pageURL := range urls[len(manifest.Pages):] {
+		manifest.Pages = append(manifest.Pages, output.ManifestEntry{URL: pageURL, Status: output.StatusPending})
+	}
+	manifest.FinishedAt = time.Now().UTC()
+	manifest.Interrupted = ctx.Err() != nil
+
+	switch {
+	case book != nil:
+		// A bundle holds whatever was collected, even after an interruption.
+		if err := writeBundle(book, rawURL, siteMeta, renderer, writer); err != nil {
+			return err
+		}
+	case stream != nil:
+		if streamErr != nil {
+			return streamErr
+		}
+	default:
+		writeRunFiles(writer, manifest)
+	}
+
+	if dupCount > 0 {
+		fmt.Fprintf(progress, "\n%d/%d pages skipped as duplicates\n", dupCount, len(urls))
+	}
+	if existCount > 0 {
+		fmt.Fprintf(progress, "\n%d/%d pages skipped as already written\n", existCount, len(urls))
+	}
 	if errCount > 0 {
 		fmt.Fprintf(os.Stderr, "\n%d/%d pages failed\n", errCount, len(urls))
 	}
+	if ctx.Err() != nil {
+		fmt.Fprintf(os.Stderr, "\nStopped after %d/%d pages\n", done, len(urls))
+		return ctx.Err()
+	}
+	return nil
+}
+
+// writeBundle renders the collected pages as one document and writes it
+// as <domain>.bundle.<ext> (or to stdout with --stdout). site is the
+// metadata of the first page, used for the document's title and language.
+func writeBundle(book *bundle.Bundle, rawURL string, site *core.PageMetadata, renderer core.Renderer, writer *output.Writer) error {
+	if book.Len() == 0 {
+		fmt.Fprintf(os.Stderr, "✗ No pages to bundle\n")
+		return nil
+	}
+	if flagBundleOrder == bundleOrderPath {
+		book.SortByPath()
+	}
+
+	parsed, _ := url.Parse(rawURL)
+	meta := core.PageMetadata{
+		URL:       rawURL,
+		Domain:    parsed.Host,
+		Path:      parsed.Path,
+		Title:     parsed.Host,
+		Language:  site.Language,
+		FetchedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if site.OpenGraph != nil && site.OpenGraph.SiteName != "" {
+		meta.Title = site.OpenGraph.SiteName
+	}
+
+	data, err := renderer.Render(book.Markdown(), meta)
+	if err != nil {
+		return fmt.Errorf("rendering bundle: %w", err)
+	}
+
+	if flagStdout {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("writing to stdout: %w", err)
+		}
+		return nil
+	}
+	path, err := writer.WriteOnly(rawURL, data, ".bundle"+renderer.Extension())
+	if errors.Is(err, output.ErrExists) {
+		fmt.Fprintf(progress, "- Skipped: %s already exists\n", path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "✓ Bundle: %s (%d pages)\n", path, book.Len())
 	return nil
 }
 
+// writeRunFiles writes the URL index, manifest and (with --index_md) table
+// of contents of a --all run. They are written even after an interruption,
+// so they always describe what is on disk.
+func writeRunFiles(writer *output.Writer, manifest *output.Manifest) {
+	if path, err := writer.WriteIndex(); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Write error: %v\n", err)
+	} else {
+		fmt.Fprintf(progress, "✓ Index: %s\n", path)
+	}
+	if path, err := writer.WriteManifest(manifest); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ Write error: %v\n", err)
+	} else {
+		fmt.Fprintf(progress, "✓ Manifest: %s\n", path)
+	}
+	if flagIndexMD {
+		if path, err := writer.WriteTOC(manifest); err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Write error: %v\n", err)
+		} else {
+			fmt.Fprintf(progress, "✓ Table of contents: %s\n", path)
+		}
+	}
+}
+
+// contentHash identifies a page's Markdown in the manifest.
+func contentHash(markdown string) string {
+	sum := sha256.Sum256([]byte(markdown))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// page holds the intermediate results for one URL as it moves
+// through the pipeline.
+type page struct {
+	URL      string
+	Markdown string
+	Meta     core.PageMetadata
+	Data     []byte // rendered output, set by renderPage
+}
+
 // processURL runs a single URL through the full pipeline.
 func processURL(
 	ctx context.Context,
@@ -187,101 +635,210 @@ func processURL(
 	extractor core.Extractor,
 	normalizer core.Normalizer,
 	renderer core.Renderer,
-) ([]byte, core.PageMetadata, error) {
+) (*page, error) {
+	pg, err := ingestURL(ctx, rawURL, fetcher, extractor, normalizer)
+	if err != nil {
+		return nil, err
+	}
+	if err := renderPage(ctx, pg, renderer); err != nil {
+		return nil, err
+	}
+	return pg, nil
+}
+
+// ingestURL runs the fetch → extract → normalize stages for a single URL.
+func ingestURL(
+	ctx context.Context,
+	rawURL string,
+	fetcher core.Fetcher,
+	extractor core.Extractor,
+	normalizer core.Normalizer,
+) (*page, error) {
 	// 1. Fetch
 	result, err := fetcher.Fetch(ctx, rawURL)
 	if err != nil {
-		return nil, core.PageMetadata{}, fmt.Errorf("fetch: %w", err)
+		return nil, fmt.Errorf("fetch: %w", err)
 	}
 
+	// Route non-HTML documents to a matching extractor.
+	extractor, normalizer = pipelineFor(result.Kind, extractor, normalizer)
+
 	// 2. Extract main content
 	content, err := extractor.Extract(result.HTML)
 	if err != nil {
-		return nil, core.PageMetadata{}, fmt.Errorf("extract: %w", err)
+		return nil, fmt.Errorf("extract: %w", err)
 	}
 
 	// 3. Normalize to Markdown
 	markdown, err := normalizer.Normalize(content)
 	if err != nil {
-		return nil, core.PageMetadata{}, fmt.Errorf("normalize: %w", err)
+		return nil, fmt.Errorf("normalize: %w", err)
 	}
 
-	// Build metadata from URL and fetched HTML.
-	meta := buildMetadata(rawURL, result.HTML)
+	// Build metadata from the fetch result and fetched HTML.
+	meta := buildMetadata(result)
 
+	return &page{URL: rawURL, Markdown: markdown, Meta: meta}, nil
+}
+
+// pipelineFor returns the extractor and normalizer for a fetched content
+// kind. HTML uses the configured pipeline; other kinds get dedicated
+// extractors, and Markdown skips conversion entirely.
+func pipelineFor(kind core.ContentKind, extractor core.Extractor, normalizer core.Normalizer) (core.Extractor, core.Normalizer) {
+	switch kind {
+	case core.ContentText:
+		return extract.NewTextExtractor(), normalizer
+	case core.ContentFeed:
+		return extract.NewFeedExtractor(), normalizer
+	case core.ContentMarkdown:
+		return extract.NewPassthrough(), normalize.NewPassthrough()
+	default:
+		return extractor, normalizer
+	}
+}
+
+// renderPage runs the render stage, storing the output in pg.Data.
+// Renderers implementing core.ContextRenderer receive ctx.
+func renderPage(ctx context.Context, pg *page, renderer core.Renderer) error {
 	// 4. Render to output format
-	data, err := renderer.Render(markdown, meta)
+	var data []byte
+	var err error
+	if cr, ok := renderer.(core.ContextRenderer); ok {
+		data, err = cr.RenderContext(ctx, pg.Markdown, pg.Meta)
+	} else {
+		data, err = renderer.Render(pg.Markdown, pg.Meta)
+	}
 	if err != nil {
-		return nil, core.PageMetadata{}, fmt.Errorf("render: %w", err)
+		return fmt.Errorf("render: %w", err)
 	}
-
-	return data, meta, nil
+	pg.Data = data
+	return nil
 }
 
-// buildMetadata constructs PageMetadata from the URL and raw HTML.
-func buildMetadata(rawURL string, html string) core.PageMetadata {
-	parsed, _ := url.Parse(rawURL)
+// buildMetadata constructs PageMetadata from the fetch result.
+// URL fields describe the final URL after redirects; document-level fields
+// come from extract.Metadata, and if the HTML cannot be parsed the URL and
+// fetch fields are still returned.
+func buildMetadata(result *core.FetchResult) core.PageMetadata {
+	pageURL := result.FinalURL
+	if pageURL == "" {
+		pageURL = result.URL
+	}
+	parsed, _ := url.Parse(pageURL)
 
-	title := extractTitle(html)
-	lang := extractLang(html)
+	// Only HTML documents carry <head> metadata.
+	meta := core.PageMetadata{Language: "en"}
+	if result.Kind == core.ContentHTML {
+		if m, err := extract.Metadata(pageURL, result.HTML); err == nil {
+			meta = m
+		}
+	}
 
-	return core.PageMetadata{
-		URL:       rawURL,
-		Domain:    parsed.Host,
-		Path:      parsed.Path,
-		Title:     title,
-		Language:  lang,
-		FetchedAt: time.Now().UTC().Format(time.RFC3339),
+	meta.URL = pageURL
+	meta.Domain = parsed.Host
+	meta.Path = parsed.Path
+	meta.FetchedAt = time.Now().UTC().Format(time.RFC3339)
+	meta.Fetch = &core.FetchInfo{
+		RequestedURL:  result.URL,
+		RedirectChain: result.RedirectChain,
+		StatusCode:    result.StatusCode,
+		ContentType:   result.ContentType,
+		Charset:       result.Charset,
+		LastModified:  result.Header.Get("Last-Modified"),
+		ETag:          result.Header.Get("ETag"),
+		Bytes:         result.Size,
+		DurationMS:    result.Duration.Milliseconds(),
 	}
+	return meta
 }
 
-// extractTitle pulls the <title> content from raw HTML.
-func extractTitle(html string) string {
-	// Simple regex-free extraction for performance.
-	start := findTag(html, "<title>")
-	if start == -1 {
-		return ""
-	}
-	// findTag returns index AFTER the tag, so for </title> we need
-	// the index BEFORE it (i.e., subtract the tag length).
-	endTag := findTag(html, "</title>")
-	if endTag == -1 || endTag <= start {
-		return ""
-	}
-	// endTag points after "</title>", subtract len("</title>") to get before it.
-	end := endTag - len("</title>")
-	if end <= start {
-		return ""
-	}
-	return html[start:end]
-}
+// buildFetcher creates the Fetcher selected by --render_js, and a cleanup
+// function that shuts down the headless browser if one was started.
+func buildFetcher(transport http.RoundTripper, auth *fetch.Auth) (core.Fetcher, func()) {
+	static := fetch.New()
+	static.MaxBodySize = int64(flagMaxBodyMB) << 20
+	static.UserAgent = flagUserAgent
+	static.UseTransport(transport)
+	static.UseAuth(auth)
+
+	if flagRenderJS == renderJSNever {
+		return static, func() {}
+	}
+
+	browser := fetch.NewBrowser()
+	browser.ExecPath = flagChromePath
+	browser.WaitSelector = flagWaitFor
+	browser.WaitTimeout = flagRenderWait
+	browser.ProxyServer = flagProxy
+	browser.UserAgent = flagUserAgent
+	browser.MaxBodySize = static.MaxBodySize
+	cleanup := func() { browser.Close() }
 
-// extractLang pulls the lang attribute from the <html> tag.
-func extractLang(html string) string {
-	idx := findTag(html, "lang=\"")
-	if idx == -1 {
-		return "en" // sensible default
+	if flagRenderJS == renderJSAlways {
+		return browser, cleanup
 	}
-	end := idx
-	for end < len(html) && html[end] != '"' {
-		end++
+	auto := fetch.NewAuto(static, browser)
+	auto.OnFallback = func(url string, err error) {
+		fmt.Fprintf(progress, "  ⚠ Warning: rendering failed, using static HTML: %v\n", err)
 	}
-	return html[idx:end]
+	return auto, cleanup
 }
 
-// findTag returns the index immediately after the given tag string.
-func findTag(html, tag string) int {
-	for i := 0; i <= len(html)-len(tag); i++ {
-		if html[i:i+len(tag)] == tag {
-			return i + len(tag)
+// httpConfig collects the network flags into an httpclient.Config.
+func httpConfig() httpclient.Config {
+	return httpclient.Config{
+		ProxyURL:        flagProxy,
+		CAFile:          flagCACert,
+		CertFile:        flagClientCert,
+		KeyFile:         flagClientKey,
+		InsecureHosts:   flagInsecureHosts,
+		MaxConnsPerHost: flagMaxConnsPerHost,
+	}
+}
+
+// buildAuth assembles cookies, headers and credentials from the
+// authentication flags. Unscoped values apply to defaultHost.
+func buildAuth(defaultHost string) (*fetch.Auth, error) {
+	auth := fetch.NewAuth()
+
+	if flagCookies != "" {
+		if err := auth.LoadCookieFile(flagCookies); err != nil {
+			return nil, err
 		}
 	}
-	return -1
+	for _, v := range flagHeaders {
+		host, header := fetch.SplitHostScope(v, defaultHost)
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf(`invalid --header %q (expected "Name: value")`, v)
+		}
+		auth.AddHeader(host, strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	for _, v := range flagBasicAuth {
+		host, creds := fetch.SplitHostScope(v, defaultHost)
+		user, password, ok := strings.Cut(creds, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf(`invalid --basic_auth for %s (expected "user:password")`, host)
+		}
+		auth.SetBasic(host, user, password)
+	}
+	for _, v := range flagBearerToken {
+		host, token := fetch.SplitHostScope(v, defaultHost)
+		if token == "" {
+			return nil, fmt.Errorf("empty --bearer_token for %s", host)
+		}
+		auth.SetBearer(host, token)
+	}
+
+	if !auth.Empty() && flagRenderJS != renderJSNever {
+		return nil, fmt.Errorf("--cookies, --header, --basic_auth and --bearer_token are not supported with --render_js")
+	}
+	return auth, nil
 }
 
 // validateFlags checks that exactly one output format is chosen and
 // that --only and --all are not both specified.
-func validateFlags() error {
+func validateFlags(cmd *cobra.Command) error {
 	// Check mutually exclusive mode flags.
 	if flagOnly && flagAll {
 		return fmt.Errorf("--only and --all are mutually exclusive")
@@ -301,14 +858,102 @@ func validateFlags() error {
 	if flagEmbeddings {
 		formatCount++
 	}
+	if flagEPUB {
+		formatCount++
+	}
+	if flagHTML {
+		formatCount++
+	}
+	if flagText {
+		formatCount++
+	}
 
 	if formatCount == 0 {
-		return fmt.Errorf("exactly one output format is required: --pdf, --markdown, --json, or --embeddings")
+		return fmt.Errorf("exactly one output format is required: --pdf, --markdown, --json, --html, --text, --epub, or --embeddings")
 	}
 	if formatCount > 1 {
 		return fmt.Errorf("only one output format allowed per run (got %d)", formatCount)
 	}
 
+	switch flagRenderJS {
+	case renderJSNever, renderJSAuto, renderJSAlways:
+	default:
+		return fmt.Errorf("--render_js must be one of never, auto, always (got %q)", flagRenderJS)
+	}
+	if (flagWaitFor != "" || flagChromePath != "") && flagRenderJS == renderJSNever {
+		return fmt.Errorf("--wait_for and --chrome_path require --render_js auto or always")
+	}
+	if (flagCACert != "" || flagClientCert != "" || flagClientKey != "" || len(flagInsecureHosts) > 0) && flagRenderJS != renderJSNever {
+		return fmt.Errorf("--ca_cert, --client_cert, --client_key and --insecure_host are not supported with --render_js")
+	}
+
+	policies := 0
+	for _, set := range []bool{flagOverwrite, flagSkipExisting, flagSuffixOnConflict} {
+		if set {
+			policies++
+		}
+	}
+	if policies > 1 {
+		return fmt.Errorf("--overwrite, --skip_existing and --suffix_on_conflict are mutually exclusive")
+	}
+
+	if flagBundle {
+		if !flagAll {
+			return fmt.Errorf("--bundle requires --all")
+		}
+		if !flagMarkdown && !flagPDF && !flagHTML && !flagText && !flagEPUB {
+			return fmt.Errorf("--bundle requires --markdown, --pdf, --html, --text or --epub")
+		}
+		if flagArchive != "" || flagIndexMD {
+			return fmt.Errorf("--bundle cannot be combined with --archive or --index_md")
+		}
+	}
+	if flagHTMLCSS != "" && !flagHTML {
+		return fmt.Errorf("--html_css requires --html")
+	}
+	for _, name := range pdfFlags {
+		if cmd.Flags().Changed(name) && !flagPDF {
+			return fmt.Errorf("--%s requires --pdf", name)
+		}
+	}
+	for _, name := range codeFlags {
+		if cmd.Flags().Changed(name) && !flagPDF && !flagHTML && !flagEPUB {
+			return fmt.Errorf("--%s requires --pdf, --html or --epub", name)
+		}
+	}
+	if flagTextWidth < 0 {
+		return fmt.Errorf("--text_width must not be negative (got %d)", flagTextWidth)
+	}
+
+	switch flagBundleOrder {
+	case bundleOrderDiscovery, bundleOrderPath:
+	default:
+		return fmt.Errorf("--bundle_order must be discovery or path (got %q)", flagBundleOrder)
+	}
+
+	if flagStdout && (flagOutputDir != "" || flagArchive != "" || flagIndexMD || policies > 0) {
+		return fmt.Errorf("--stdout cannot be combined with --output_dir, --archive, --index_md or the overwrite flags")
+	}
+	if flagArchive != "" {
+		if !flagAll {
+			return fmt.Errorf("--archive requires --all")
+		}
+		if flagOutputDir != "" {
+			return fmt.Errorf("--archive and --output_dir are mutually exclusive")
+		}
+		if output.ArchiveExt(flagArchive) == "" {
+			return fmt.Errorf("--archive must end in .zip, .tar.gz or .tgz (got %q)", flagArchive)
+		}
+	}
+
+	if strings.TrimSpace(flagUserAgent) == "" {
+		return fmt.Errorf("--user_agent must not be empty")
+	}
+
+	if flagMaxBodyMB <= 0 {
+		return fmt.Errorf("--max_body_mb must be positive (got %d)", flagMaxBodyMB)
+	}
+
 	// --model is required with --embeddings.
 	if flagEmbeddings && flagModel == "" {
 		return fmt.Errorf("--model is required when using --embeddings")
@@ -317,17 +962,129 @@ func validateFlags() error {
 	return nil
 }
 
+// buildWriter creates the output writer: an archive when --archive is
+// given, the output directory otherwise.
+func buildWriter() (*output.Writer, error) {
+	if flagArchive != "" {
+		return output.NewArchive(flagArchive, writePolicy())
+	}
+	writer, err := output.New(flagOutputDir)
+	if err != nil {
+		return nil, err
+	}
+	writer.Policy = writePolicy()
+	return writer, nil
+}
+
+// writePolicy maps the overwrite flags to an output.Policy.
+func writePolicy() output.Policy {
+	switch {
+	case flagSkipExisting:
+		return output.SkipExisting
+	case flagSuffixOnConflict:
+		return output.SuffixOnConflict
+	default:
+		return output.Overwrite
+	}
+}
+
+// pdfFlags and codeFlags only apply to some output formats.
+var (
+	pdfFlags  = []string{"pdf_font", "pdf_page_size", "pdf_orientation", "pdf_margins", "pdf_font_size", "pdf_page_numbers", "pdf_header", "pdf_toc", "pdf_outline"}
+	codeFlags = []string{"code_style", "code_line_numbers"}
+)
+
+// pdfLayout builds the PDF layout from the --pdf_* flags. The renderer
+// checks that the result can be laid out.
+func pdfLayout() (render.PDFLayout, error) {
+	layout := render.DefaultPDFLayout()
+	layout.PageSize = flagPDFPageSize
+	switch flagPDFOrientation {
+	case "portrait":
+	case "landscape":
+		layout.Landscape = true
+	default:
+		return layout, fmt.Errorf("--pdf_orientation must be portrait or landscape (got %q)", flagPDFOrientation)
+	}
+	margins, err := render.ParsePDFMargins(flagPDFMargins)
+	if err != nil {
+		return layout, fmt.Errorf("--pdf_margins: %w", err)
+	}
+	layout.Margins = margins
+	layout.FontSize = flagPDFFontSize
+	layout.PageNumbers = flagPDFPageNumbers
+	layout.Header = flagPDFHeader
+	layout.TOC = flagPDFTOC
+	layout.Outline = flagPDFOutline
+	return layout, nil
+}
+
+// codeOptions builds the code block options from --code_style and
+// --code_line_numbers.
+func codeOptions() render.CodeOptions {
+	opts := render.CodeOptions{Style: flagCodeStyle, LineNumbers: flagCodeLineNumbers}
+	if flagCodeStyle == "none" {
+		opts.Style = ""
+	}
+	return opts
+}
+
 // selectRenderer creates the appropriate Renderer based on flags.
-func selectRenderer() (core.Renderer, error) {
+// Renderers that make network calls use transport.
+func selectRenderer(transport http.RoundTripper) (core.Renderer, error) {
 	switch {
 	case flagMarkdown:
 		return render.NewMarkdownRenderer(), nil
 	case flagJSON:
 		return render.NewJSONRenderer(), nil
 	case flagPDF:
-		return render.NewPDFRenderer(), nil
+		r := render.NewPDFRenderer()
+		layout, err := pdfLayout()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.UseLayout(layout); err != nil {
+			return nil, err
+		}
+		if err := r.UseCodeOptions(codeOptions()); err != nil {
+			return nil, fmt.Errorf("--code_style: %w", err)
+		}
+		if flagPDFFont != "" {
+			if err := r.UseFont(flagPDFFont); err != nil {
+				return nil, err
+			}
+		}
+		return r, nil
+	case flagHTML:
+		r := render.NewHTMLRenderer()
+		if err := r.UseCodeOptions(codeOptions()); err != nil {
+			return nil, fmt.Errorf("--code_style: %w", err)
+		}
+		switch flagHTMLCSS {
+		case "":
+		case "none":
+			r.Stylesheet = ""
+		default:
+			css, err := os.ReadFile(flagHTMLCSS)
+			if err != nil {
+				return nil, fmt.Errorf("reading --html_css: %w", err)
+			}
+			r.Stylesheet = string(css)
+		}
+		return r, nil
+	case flagText:
+		return render.NewTextRenderer(flagTextWidth), nil
+	case flagEPUB:
+		r := render.NewEPUBRenderer()
+		if err := r.UseCodeOptions(codeOptions()); err != nil {
+			return nil, fmt.Errorf("--code_style: %w", err)
+		}
+		return r, nil
 	case flagEmbeddings:
-		return render.NewEmbeddingsRenderer(flagModel, flagChunkSize), nil
+		r := render.NewEmbeddingsRenderer(flagModel, flagChunkSize)
+		r.UserAgent = flagUserAgent
+		r.UseTransport(transport)
+		return r, nil
 	default:
 		return nil, fmt.Errorf("no output format selected")
 	}
@@ -2,6 +2,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -15,13 +16,18 @@ var rootCmd = &cobra.Command{
 into Markdown, PDF, JSON, or Embeddings.
 
 Usage:
-  pagepipe convert <url> [flags]`,
+  pagepipe convert <url> [flags]
+  pagepipe schema [flags]`,
 }
 
 // Execute runs the root command.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		var interrupted *interruptedError
+		if errors.As(err, &interrupted) {
+			os.Exit(interrupted.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
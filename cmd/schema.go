@@ -0,0 +1,100 @@
+// Package cmd — schema command.
+// Prints the JSON Schema of --json output, generated from the Go types,
+// and validates existing JSON outputs against it.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gaurav-prasanna/pagepipe/core"
+	"github.com/gaurav-prasanna/pagepipe/core/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagSchemaOutput   string
+	flagSchemaValidate bool
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema [file.json ...]",
+	Short: "Print the JSON Schema of --json output, or validate JSON files against it",
+	Long: `Schema prints the JSON Schema (draft 2020-12) describing the output of
+"pagepipe convert --json". The schema is generated from the same Go types
+the JSON renderer uses; schema_version identifies the layout.
+
+With --validate, each file given is checked against the schema instead.
+
+Examples:
+  pagepipe schema > page.schema.json
+  pagepipe schema --validate out/example.com/*.json`,
+	RunE: runSchema,
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+
+	schemaCmd.Flags().StringVar(&flagSchemaOutput, "output", "", "Write the schema to this file instead of stdout")
+	schemaCmd.Flags().BoolVar(&flagSchemaValidate, "validate", false, "Validate the given JSON files against the schema")
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	s := schema.PageJSON()
+
+	if !flagSchemaValidate {
+		if len(args) > 0 {
+			return fmt.Errorf("files are only accepted with --validate")
+		}
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding schema: %w", err)
+		}
+		data = append(data, '\n')
+		if flagSchemaOutput == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(flagSchemaOutput, data, 0o644); err != nil {
+			return fmt.Errorf("writing schema: %w", err)
+		}
+		fmt.Printf("✓ Written: %s (schema version %d)\n", flagSchemaOutput, core.JSONSchemaVersion)
+		return nil
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("--validate requires at least one JSON file")
+	}
+	if flagSchemaOutput != "" {
+		return fmt.Errorf("--output cannot be combined with --validate")
+	}
+
+	// From here on errors are about the files, not the command line.
+	cmd.SilenceUsage = true
+
+	invalid := 0
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		problems, err := schema.Validate(s, data)
+		if err != nil {
+			problems = []schema.ValidationError{{Message: err.Error()}}
+		}
+		if len(problems) == 0 {
+			fmt.Printf("✓ Valid: %s\n", path)
+			continue
+		}
+		invalid++
+		fmt.Printf("✗ Invalid: %s\n", path)
+		for _, p := range problems {
+			fmt.Printf("  ✗ %s\n", p.Error())
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d files do not match schema version %d", invalid, len(args), core.JSONSchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package cmd — signal handling.
+// SIGINT and SIGTERM cancel the run's context so the pipeline can stop
+// between pages instead of dying mid-write. A second signal exits
+// immediately.
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// interruptedError is returned when a run was stopped by a signal.
+// Execute exits with 128+signal, as shells report signal deaths.
+type interruptedError struct {
+	sig os.Signal
+}
+
+func (e *interruptedError) Error() string {
+	return fmt.Sprintf("interrupted by %s", signalName(e.sig))
+}
+
+// ExitCode returns the process exit status for the signal.
+func (e *interruptedError) ExitCode() int {
+	if s, ok := e.sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 130
+}
+
+// signalName returns the conventional name of the handled signals.
+func signalName(sig os.Signal) string {
+	switch sig {
+	case os.Interrupt:
+		return "SIGINT"
+	case syscall.SIGTERM:
+		return "SIGTERM"
+	}
+	return sig.String()
+}
+
+// signalContext returns a context cancelled on the first SIGINT or SIGTERM,
+// a function reporting which signal (if any) was received, and a stop
+// function releasing the handler. After the first signal the default
+// handler is restored, so a second one terminates the process.
+func signalContext(parent context.Context) (context.Context, func() os.Signal, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+
+	var mu sync.Mutex
+	var received os.Signal
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-ch:
+			mu.Lock()
+			received = sig
+			mu.Unlock()
+			signal.Stop(ch)
+			fmt.Fprintf(os.Stderr, "\n%s received: finishing the current page (press Ctrl-C again to abort)\n", signalName(sig))
+			cancel()
+		case <-done:
+		}
+	}()
+
+	signalled := func() os.Signal {
+		mu.Lock()
+		defer mu.Unlock()
+		return received
+	}
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			cancel()
+		})
+	}
+	return ctx, signalled, stop
+}
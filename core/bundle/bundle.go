@@ -0,0 +1,173 @@
+// Package bundle combines the pages of a crawl into one Markdown document
+// with a table of contents. Links between bundled pages are rewritten to
+// in-document anchors and all other relative links are made absolute, so
+// the result reads as a single self-contained book.
+package bundle
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Page is one page of the bundle.
+type Page struct {
+	URL      string
+	Aliases  []string // other URLs of the page (final, canonical)
+	Title    string
+	Markdown string
+}
+
+// Bundle collects pages in the order they are added.
+type Bundle struct {
+	pages     []Page
+	normalize func(string) string
+}
+
+// New creates an empty Bundle. normalize maps a URL to the key used to
+// recognise links between pages (e.g. crawl.NormalizeURL); nil compares
+// URLs verbatim, ignoring fragments.
+func New(normalize func(string) string) *Bundle {
+	if normalize == nil {
+		normalize = func(s string) string { return s }
+	}
+	return &Bundle{normalize: normalize}
+}
+
+// Add appends a page.
+func (b *Bundle) Add(p Page) {
+	b.pages = append(b.pages, p)
+}
+
+// Len returns the number of pages added.
+func (b *Bundle) Len() int {
+	return len(b.pages)
+}
+
+// SortByPath orders pages by their URL path hierarchy, so every page
+// follows its parent; siblings keep the order they were added in.
+func (b *Bundle) SortByPath() {
+	segments := func(p Page) []string {
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			return nil
+		}
+		path := strings.Trim(u.Path, "/")
+		if path == "" {
+			return nil
+		}
+		return strings.Split(path, "/")
+	}
+
+	// Each path prefix takes the position of the first page under it, so
+	// sections stay together in crawl order.
+	first := make(map[string]int)
+	for i, p := range b.pages {
+		segs := segments(p)
+		for n := 0; n <= len(segs); n++ {
+			prefix := strings.Join(segs[:n], "/")
+			if _, ok := first[prefix]; !ok {
+				first[prefix] = i
+			}
+		}
+	}
+
+	keys := make([][]int, len(b.pages))
+	for i, p := range b.pages {
+		segs := segments(p)
+		for n := 1; n <= len(segs); n++ {
+			keys[i] = append(keys[i], first[strings.Join(segs[:n], "/")])
+		}
+	}
+	idx := make([]int, len(b.pages))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(x, y int) bool {
+		a, c := keys[idx[x]], keys[idx[y]]
+		for n := 0; n < len(a) && n < len(c); n++ {
+			if a[n] != c[n] {
+				return a[n] < c[n]
+			}
+		}
+		return len(a) < len(c)
+	})
+
+	sorted := make([]Page, len(b.pages))
+	for i, j := range idx {
+		sorted[i] = b.pages[j]
+	}
+	b.pages = sorted
+}
+
+// Anchor returns the in-document anchor of the i-th page.
+func Anchor(i int) string {
+	return fmt.Sprintf("page-%d", i+1)
+}
+
+// Markdown renders the bundle: a "Contents" list linking every page, then
+// each page under its own level-1 heading, preceded by an HTML anchor.
+// Page headings are demoted one level to nest under the page title.
+func (b *Bundle) Markdown() string {
+	anchors := make(map[string]string)
+	for i, p := range b.pages {
+		for _, u := range append([]string{p.URL}, p.Aliases...) {
+			if key := b.key(u); key != "" {
+				if _, taken := anchors[key]; !taken {
+					anchors[key] = Anchor(i)
+				}
+			}
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString("# Contents\n\n")
+	for i, p := range b.pages {
+		fmt.Fprintf(&out, "- [%s](#%s)\n", escapeLinkText(pageTitle(p)), Anchor(i))
+	}
+
+	for i, p := range b.pages {
+		fmt.Fprintf(&out, "\n<a id=\"%s\"></a>\n\n# %s\n\n", Anchor(i), pageTitle(p))
+		fmt.Fprintf(&out, "*Source: %s*\n\n", p.URL)
+		body := dropTitleHeading(p.Markdown, pageTitle(p))
+		body = b.rewrite(body, p.URL, anchors)
+		out.WriteString(strings.TrimSpace(body))
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// key normalizes a URL for link matching, without its fragment.
+func (b *Bundle) key(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() {
+		return ""
+	}
+	u.Fragment, u.RawFragment = "", ""
+	return b.normalize(u.String())
+}
+
+// pageTitle returns the page's title, falling back to its URL.
+func pageTitle(p Page) string {
+	if t := strings.TrimSpace(p.Title); t != "" {
+		return t
+	}
+	return p.URL
+}
+
+// dropTitleHeading removes a leading level-1 heading that repeats the
+// page title, since the bundle adds its own.
+func dropTitleHeading(markdown, title string) string {
+	trimmed := strings.TrimLeft(markdown, "\n")
+	first, rest, _ := strings.Cut(trimmed, "\n")
+	if strings.HasPrefix(first, "# ") && strings.EqualFold(strings.TrimSpace(first[2:]), strings.TrimSpace(title)) {
+		return rest
+	}
+	return markdown
+}
+
+// escapeLinkText escapes characters that would end a Markdown link label.
+func escapeLinkText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,188 @@
+// Package bundle — link rewriting.
+// Pages are parsed with goldmark so that only real links, images and
+// headings are edited; code blocks (fenced with ``` or ~~~, or indented),
+// code spans and raw HTML are left exactly as written.
+package bundle
+
+import (
+	"bytes"
+	"net/url"
+	"sort"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// linkEndsKey holds, per parse, the source offset of the "]" closing the
+// text of each inline link and image.
+var linkEndsKey = parser.NewContextKey()
+
+// positionedLinkParser wraps goldmark's link parser to record where each
+// link's text ends, since inline nodes carry no source positions and the
+// destination has to be edited in place.
+type positionedLinkParser struct {
+	parser.InlineParser
+}
+
+func (p positionedLinkParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	_, pos := block.Position()
+	n := p.InlineParser.Parse(parent, block, pc)
+	switch n.(type) {
+	case *ast.Link, *ast.Image:
+		if block.Source()[pos.Start] == ']' {
+			ends, _ := pc.Get(linkEndsKey).(map[ast.Node]int)
+			if ends == nil {
+				ends = make(map[ast.Node]int)
+				pc.Set(linkEndsKey, ends)
+			}
+			ends[n] = pos.Start
+		}
+	}
+	return n
+}
+
+func (p positionedLinkParser) CloseBlock(parent ast.Node, block text.Reader, pc parser.Context) {
+	if closer, ok := p.InlineParser.(parser.CloseBlocker); ok {
+		closer.CloseBlock(parent, block, pc)
+	}
+}
+
+// pageParser is goldmark's CommonMark parser with the link parser
+// replaced by positionedLinkParser.
+var pageParser = func() parser.Parser {
+	inline := parser.DefaultInlineParsers()
+	for i, v := range inline {
+		if lp, ok := v.Value.(parser.InlineParser); ok && bytes.Contains(lp.Trigger(), []byte("]")) {
+			inline[i] = util.Prioritized(positionedLinkParser{lp}, v.Priority)
+		}
+	}
+	return parser.NewParser(
+		parser.WithBlockParsers(parser.DefaultBlockParsers()...),
+		parser.WithInlineParsers(inline...),
+		parser.WithParagraphTransformers(parser.DefaultParagraphTransformers()...),
+	)
+}()
+
+// edit replaces source[start:end] with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// rewrite demotes headings and rewrites link targets in one page's
+// Markdown: links to bundled pages become in-document anchors, and other
+// relative links and images are made absolute.
+func (b *Bundle) rewrite(markdown, pageURL string, anchors map[string]string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return markdown
+	}
+	source := []byte(markdown)
+	pc := parser.NewContext()
+	doc := pageParser.Parse(text.NewReader(source), parser.WithContext(pc))
+	ends, _ := pc.Get(linkEndsKey).(map[ast.Node]int)
+
+	var edits []edit
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Heading:
+			if at, ok := atxMarker(n, source); ok && n.Level < 6 {
+				edits = append(edits, edit{at, at, "#"})
+			}
+		case *ast.Link:
+			if target, ok := b.target(n.Destination, false, base, anchors); ok {
+				edits = appendDestination(edits, source, ends, n, n.Destination, target)
+			}
+		case *ast.Image:
+			if target, ok := b.target(n.Destination, true, base, anchors); ok {
+				edits = appendDestination(edits, source, ends, n, n.Destination, target)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return applyEdits(source, edits)
+}
+
+// target returns the rewritten destination of a link or image, if it
+// changes.
+func (b *Bundle) target(dest []byte, image bool, base *url.URL, anchors map[string]string) (string, bool) {
+	if len(dest) == 0 {
+		return "", false
+	}
+	ref, err := url.Parse(string(dest))
+	if err != nil {
+		return "", false
+	}
+	if !image && ref.Scheme == "" && ref.Host == "" && ref.Path == "" {
+		// Fragment-only links point into this page; its headings have no
+		// stable anchors, so they are kept as written.
+		return "", false
+	}
+	abs := base.ResolveReference(ref).String()
+	if !image {
+		if anchor, ok := anchors[b.key(abs)]; ok {
+			return "#" + anchor, true
+		}
+	}
+	return abs, abs != string(dest)
+}
+
+// appendDestination adds the edit replacing the destination of the
+// inline link or image n with target. Reference links, whose destination
+// is in a separate definition, are left alone.
+func appendDestination(edits []edit, source []byte, ends map[ast.Node]int, n ast.Node, dest []byte, target string) []edit {
+	i, ok := ends[n]
+	if !ok || !bytes.HasPrefix(source[i:], []byte("](")) {
+		return edits
+	}
+	i += 2
+	for i < len(source) && util.IsSpace(source[i]) {
+		i++
+	}
+	if i < len(source) && source[i] == '<' {
+		i++
+	}
+	if !bytes.HasPrefix(source[i:], dest) {
+		return edits
+	}
+	return append(edits, edit{i, i + len(dest), target})
+}
+
+// atxMarker returns the offset of the "#" run of an ATX heading; setext
+// and empty headings report false.
+func atxMarker(h *ast.Heading, source []byte) (int, bool) {
+	if h.Lines().Len() == 0 {
+		return 0, false
+	}
+	i := h.Lines().At(0).Start
+	for i > 0 && (source[i-1] == ' ' || source[i-1] == '\t') {
+		i--
+	}
+	end := i
+	for i > 0 && source[i-1] == '#' {
+		i--
+	}
+	return i, i < end
+}
+
+// applyEdits returns source with the non-overlapping edits applied.
+func applyEdits(source []byte, edits []edit) string {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var out bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			continue
+		}
+		out.Write(source[last:e.start])
+		out.WriteString(e.text)
+		last = e.end
+	}
+	out.Write(source[last:])
+	return out.String()
+}
@@ -0,0 +1,145 @@
+// Package extract — RSS and Atom feeds.
+// A feed is rendered as an HTML fragment with the feed title followed by
+// one section per item, so it flows through the normal HTML → Markdown
+// normalizer like any other page.
+package extract
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// FeedExtractor converts RSS 0.9x/1.0/2.0 and Atom documents into HTML.
+type FeedExtractor struct{}
+
+// NewFeedExtractor creates a FeedExtractor.
+func NewFeedExtractor() *FeedExtractor {
+	return &FeedExtractor{}
+}
+
+// feedDoc captures the elements shared by RSS and Atom. Unused fields of
+// the other format simply stay empty.
+type feedDoc struct {
+	XMLName xml.Name
+
+	// RSS 2.0: <rss><channel>...</channel></rss>
+	Channel *feedChannel `xml:"channel"`
+	// RSS 1.0 (RDF) puts items next to the channel.
+	RDFItems []feedItem `xml:"item"`
+
+	// Atom: <feed>...</feed>
+	Title    string     `xml:"title"`
+	Subtitle string     `xml:"subtitle"`
+	Entries  []feedItem `xml:"entry"`
+}
+
+type feedChannel struct {
+	Title       string     `xml:"title"`
+	Description string     `xml:"description"`
+	Items       []feedItem `xml:"item"`
+}
+
+type feedItem struct {
+	Title       string     `xml:"title"`
+	Links       []feedLink `xml:"link"`
+	Description string     `xml:"description"`
+	Summary     string     `xml:"summary"`
+	Content     string     `xml:"content"`
+	Encoded     string     `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string     `xml:"pubDate"`
+	Updated     string     `xml:"updated"`
+	Published   string     `xml:"published"`
+}
+
+// feedLink is either an RSS <link>url</link> or an Atom <link href="url"/>.
+type feedLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Text string `xml:",chardata"`
+}
+
+// Extract parses the feed and returns an HTML fragment.
+func (e *FeedExtractor) Extract(doc string) (string, error) {
+	dec := xml.NewDecoder(strings.NewReader(doc))
+	// The fetcher has already transcoded the body to UTF-8, honouring the
+	// encoding in the XML declaration, so the declaration is ignored here.
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+	dec.Strict = false
+
+	var feed feedDoc
+	if err := dec.Decode(&feed); err != nil {
+		return "", fmt.Errorf("parsing feed: %w", err)
+	}
+
+	var title, description string
+	var items []feedItem
+	switch strings.ToLower(feed.XMLName.Local) {
+	case "rss", "rdf":
+		if feed.Channel != nil {
+			title, description = feed.Channel.Title, feed.Channel.Description
+			items = feed.Channel.Items
+		}
+		items = append(items, feed.RDFItems...)
+	case "feed":
+		title, description = feed.Title, feed.Subtitle
+		items = feed.Entries
+	default:
+		return "", fmt.Errorf("unsupported XML document <%s>", feed.XMLName.Local)
+	}
+
+	var b strings.Builder
+	b.WriteString("<article>")
+	if title = strings.TrimSpace(title); title != "" {
+		fmt.Fprintf(&b, "<h1>%s</h1>", html.EscapeString(title))
+	}
+	if description = strings.TrimSpace(description); description != "" {
+		fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(description))
+	}
+	for _, item := range items {
+		writeFeedItem(&b, item)
+	}
+	b.WriteString("</article>")
+	return b.String(), nil
+}
+
+// writeFeedItem writes one item as a <section>. Item bodies are HTML in
+// both formats and are embedded as-is for the normalizer to convert.
+func writeFeedItem(b *strings.Builder, item feedItem) {
+	b.WriteString("<section>")
+
+	title := html.EscapeString(strings.TrimSpace(item.Title))
+	if title == "" {
+		title = "Untitled"
+	}
+	if link := item.link(); link != "" {
+		fmt.Fprintf(b, `<h2><a href="%s">%s</a></h2>`, html.EscapeString(link), title)
+	} else {
+		fmt.Fprintf(b, "<h2>%s</h2>", title)
+	}
+
+	if date := firstNonEmpty(item.PubDate, item.Published, item.Updated); date != "" {
+		fmt.Fprintf(b, "<p><em>%s</em></p>", html.EscapeString(strings.TrimSpace(date)))
+	}
+	if body := firstNonEmpty(item.Encoded, item.Content, item.Description, item.Summary); body != "" {
+		fmt.Fprintf(b, "<div>%s</div>", body)
+	}
+
+	b.WriteString("</section>")
+}
+
+// link returns the item's primary URL: the RSS link text, or the Atom
+// link with rel="alternate" (or no rel).
+func (item feedItem) link() string {
+	for _, l := range item.Links {
+		if t := strings.TrimSpace(l.Text); t != "" {
+			return t
+		}
+		if l.Href != "" && (l.Rel == "" || l.Rel == "alternate") {
+			return l.Href
+		}
+	}
+	return ""
+}
@@ -0,0 +1,343 @@
+// Package extract — page metadata.
+// Reads document-level metadata from the raw HTML: <title>, <html lang>,
+// standard <meta> tags, the canonical link, OpenGraph and Twitter card
+// properties, and JSON-LD Article / BreadcrumbList blocks.
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gaurav-prasanna/pagepipe/core"
+)
+
+// defaultLanguage is used when the page does not declare one.
+const defaultLanguage = "en"
+
+// Metadata parses the raw HTML and returns the document-derived fields of
+// PageMetadata. URL, Domain, Path and FetchedAt are left for the caller.
+// pageURL is used to resolve a relative canonical link.
+func Metadata(pageURL string, html string) (core.PageMetadata, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return core.PageMetadata{}, fmt.Errorf("parsing HTML: %w", err)
+	}
+
+	tags := collectMetaTags(doc)
+	og := openGraphFrom(tags)
+	tw := twitterCardFrom(tags)
+	article, crumbs := parseJSONLD(doc)
+
+	meta := core.PageMetadata{
+		Title:        strings.TrimSpace(doc.Find("title").First().Text()),
+		Language:     strings.TrimSpace(doc.Find("html").First().AttrOr("lang", "")),
+		Description:  tags["description"],
+		CanonicalURL: canonicalURL(doc, pageURL),
+		Author:       tags["author"],
+		PublishedAt:  firstNonEmpty(tags["article:published_time"], tags["date"]),
+		ModifiedAt:   firstNonEmpty(tags["article:modified_time"], tags["og:updated_time"]),
+		Keywords:     splitKeywords(tags["keywords"]),
+		OpenGraph:    og,
+		Twitter:      tw,
+		Article:      article,
+		Breadcrumbs:  crumbs,
+	}
+
+	// Fill gaps from the social and structured-data sources, in order of
+	// how reliably each one describes the page itself.
+	if og != nil {
+		meta.Title = firstNonEmpty(meta.Title, og.Title)
+		meta.Description = firstNonEmpty(meta.Description, og.Description)
+		meta.Language = firstNonEmpty(meta.Language, localeToLang(og.Locale))
+	}
+	if tw != nil {
+		meta.Title = firstNonEmpty(meta.Title, tw.Title)
+		meta.Description = firstNonEmpty(meta.Description, tw.Description)
+	}
+	if article != nil {
+		meta.Title = firstNonEmpty(meta.Title, article.Headline)
+		meta.Author = firstNonEmpty(meta.Author, strings.Join(article.Authors, ", "))
+		meta.PublishedAt = firstNonEmpty(meta.PublishedAt, article.DatePublished)
+		meta.ModifiedAt = firstNonEmpty(meta.ModifiedAt, article.DateModified)
+	}
+	meta.Author = firstNonEmpty(meta.Author, tags["article:author"])
+	meta.Language = firstNonEmpty(meta.Language, tags["content-language"], defaultLanguage)
+
+	return meta, nil
+}
+
+// collectMetaTags returns the first non-empty content of every <meta> tag,
+// keyed by its lowercased name, property or http-equiv attribute.
+func collectMetaTags(doc *goquery.Document) map[string]string {
+	tags := make(map[string]string)
+	doc.Find("meta[content]").Each(func(_ int, s *goquery.Selection) {
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+		if content == "" {
+			return
+		}
+		for _, attr := range []string{"name", "property", "http-equiv"} {
+			key := strings.ToLower(strings.TrimSpace(s.AttrOr(attr, "")))
+			if key == "" {
+				continue
+			}
+			if _, seen := tags[key]; !seen {
+				tags[key] = content
+			}
+		}
+	})
+	return tags
+}
+
+// openGraphFrom builds the OpenGraph block, or nil if the page has none.
+func openGraphFrom(tags map[string]string) *core.OpenGraph {
+	og := core.OpenGraph{
+		Title:       tags["og:title"],
+		Description: tags["og:description"],
+		Type:        tags["og:type"],
+		URL:         tags["og:url"],
+		Image:       firstNonEmpty(tags["og:image"], tags["og:image:url"]),
+		SiteName:    tags["og:site_name"],
+		Locale:      tags["og:locale"],
+	}
+	if og == (core.OpenGraph{}) {
+		return nil
+	}
+	return &og
+}
+
+// twitterCardFrom builds the Twitter card block, or nil if the page has none.
+func twitterCardFrom(tags map[string]string) *core.TwitterCard {
+	tw := core.TwitterCard{
+		Card:        tags["twitter:card"],
+		Title:       tags["twitter:title"],
+		Description: tags["twitter:description"],
+		Image:       firstNonEmpty(tags["twitter:image"], tags["twitter:image:src"]),
+		Site:        tags["twitter:site"],
+		Creator:     tags["twitter:creator"],
+	}
+	if tw == (core.TwitterCard{}) {
+		return nil
+	}
+	return &tw
+}
+
+// canonicalURL returns the <link rel="canonical"> href resolved against pageURL.
+func canonicalURL(doc *goquery.Document, pageURL string) string {
+	var href string
+	doc.Find("link[rel][href]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			if rel == "canonical" {
+				href = strings.TrimSpace(s.AttrOr("href", ""))
+				return false
+			}
+		}
+		return true
+	})
+	if href == "" {
+		return ""
+	}
+
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ref.String()
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// splitKeywords splits a comma-separated keywords value.
+func splitKeywords(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var out []string
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// localeToLang converts an OpenGraph locale (en_US) to a language tag (en-US).
+func localeToLang(locale string) string {
+	return strings.ReplaceAll(locale, "_", "-")
+}
+
+// firstNonEmpty returns the first argument that is not blank.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// --- JSON-LD ---
+
+// articleTypes are the schema.org types treated as an Article.
+var articleTypes = map[string]bool{
+	"Article": true, "NewsArticle": true, "BlogPosting": true,
+	"TechArticle": true, "ScholarlyArticle": true, "Report": true,
+}
+
+// parseJSONLD scans every JSON-LD script block and returns the first
+// Article-like node and the first BreadcrumbList found. Malformed blocks
+// are skipped; structured data is best-effort.
+func parseJSONLD(doc *goquery.Document) (*core.Article, []core.Breadcrumb) {
+	var article *core.Article
+	var crumbs []core.Breadcrumb
+
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var raw any
+		if err := json.Unmarshal([]byte(s.Text()), &raw); err != nil {
+			return
+		}
+		for _, node := range flattenLD(raw) {
+			types := ldTypes(node["@type"])
+			switch {
+			case article == nil && anyOf(types, articleTypes):
+				article = articleFrom(node, types)
+			case crumbs == nil && anyOf(types, map[string]bool{"BreadcrumbList": true}):
+				crumbs = breadcrumbsFrom(node)
+			}
+		}
+	})
+	return article, crumbs
+}
+
+// flattenLD returns all top-level nodes of a JSON-LD value, expanding
+// arrays and @graph containers.
+func flattenLD(v any) []map[string]any {
+	var nodes []map[string]any
+	switch t := v.(type) {
+	case []any:
+		for _, item := range t {
+			nodes = append(nodes, flattenLD(item)...)
+		}
+	case map[string]any:
+		if graph, ok := t["@graph"]; ok {
+			nodes = append(nodes, flattenLD(graph)...)
+		}
+		if _, ok := t["@type"]; ok {
+			nodes = append(nodes, t)
+		}
+	}
+	return nodes
+}
+
+// ldTypes returns the @type value as a list (it may be a string or an array).
+func ldTypes(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var out []string
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func anyOf(types []string, set map[string]bool) bool {
+	for _, t := range types {
+		if set[t] {
+			return true
+		}
+	}
+	return false
+}
+
+func articleFrom(node map[string]any, types []string) *core.Article {
+	a := &core.Article{
+		Headline:      ldString(node["headline"]),
+		Authors:       ldNames(node["author"]),
+		Publisher:     strings.Join(ldNames(node["publisher"]), ", "),
+		DatePublished: ldString(node["datePublished"]),
+		DateModified:  ldString(node["dateModified"]),
+		Section:       ldString(node["articleSection"]),
+	}
+	for _, t := range types {
+		if articleTypes[t] {
+			a.Type = t
+			break
+		}
+	}
+	return a
+}
+
+func breadcrumbsFrom(node map[string]any) []core.Breadcrumb {
+	items, _ := node["itemListElement"].([]any)
+	crumbs := make([]core.Breadcrumb, 0, len(items))
+	for i, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		c := core.Breadcrumb{
+			Position: i + 1,
+			Name:     ldString(m["name"]),
+		}
+		if pos, ok := m["position"].(float64); ok {
+			c.Position = int(pos)
+		}
+		// "item" is either the URL itself or a Thing with @id / url / name.
+		switch it := m["item"].(type) {
+		case string:
+			c.URL = it
+		case map[string]any:
+			c.URL = firstNonEmpty(ldString(it["@id"]), ldString(it["url"]))
+			c.Name = firstNonEmpty(c.Name, ldString(it["name"]))
+		}
+		crumbs = append(crumbs, c)
+	}
+	sort.SliceStable(crumbs, func(i, j int) bool { return crumbs[i].Position < crumbs[j].Position })
+	return crumbs
+}
+
+// ldString returns v if it is a string, or the first string of an array.
+func ldString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t)
+	case []any:
+		if len(t) > 0 {
+			return ldString(t[0])
+		}
+	}
+	return ""
+}
+
+// ldNames returns the names of a Person/Organization value, which may be a
+// plain string, a single object or an array of either.
+func ldNames(v any) []string {
+	switch t := v.(type) {
+	case string:
+		if t = strings.TrimSpace(t); t != "" {
+			return []string{t}
+		}
+	case map[string]any:
+		if name := ldString(t["name"]); name != "" {
+			return []string{name}
+		}
+	case []any:
+		var out []string
+		for _, item := range t {
+			out = append(out, ldNames(item)...)
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Package extract — plain text and Markdown documents.
+// Plain text is wrapped in HTML paragraphs so it flows through the normal
+// HTML → Markdown normalizer; Markdown is passed through untouched.
+package extract
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// blankLines splits plain text into paragraphs.
+var blankLines = regexp.MustCompile(`\n[ \t]*\n`)
+
+// TextExtractor converts a plain-text document into an HTML fragment.
+type TextExtractor struct{}
+
+// NewTextExtractor creates a TextExtractor.
+func NewTextExtractor() *TextExtractor {
+	return &TextExtractor{}
+}
+
+// Extract escapes the text and wraps each blank-line separated block in a
+// <p>, keeping line breaks within a block.
+func (e *TextExtractor) Extract(text string) (string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var b strings.Builder
+	b.WriteString("<article>")
+	for _, para := range blankLines.Split(text, -1) {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		lines := strings.Split(para, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(strings.TrimRight(line, " \t"))
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.Join(lines, "<br>"))
+		b.WriteString("</p>")
+	}
+	b.WriteString("</article>")
+	return b.String(), nil
+}
+
+// PassthroughExtractor returns its input unchanged. It is used for
+// documents that are already Markdown.
+type PassthroughExtractor struct{}
+
+// NewPassthrough creates a PassthroughExtractor.
+func NewPassthrough() *PassthroughExtractor {
+	return &PassthroughExtractor{}
+}
+
+// Extract returns the document as-is.
+func (e *PassthroughExtractor) Extract(doc string) (string, error) {
+	return doc, nil
+}
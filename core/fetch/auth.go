@@ -0,0 +1,226 @@
+// Package fetch — authentication.
+// Cookies, custom headers and basic/bearer credentials for fetching
+// private sites. Headers and credentials are scoped to a host, so they
+// are never sent to another host, including across redirects.
+package fetch
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// maxRedirects matches net/http's default redirect limit.
+const maxRedirects = 10
+
+// Auth holds per-host request headers and credentials plus a cookie jar.
+// A nil *Auth is valid and adds nothing.
+type Auth struct {
+	// Jar supplies cookies; it is consulted per request URL, so cookies
+	// follow their own domain rules.
+	Jar http.CookieJar
+
+	hosts map[string]*hostAuth
+}
+
+// hostAuth is the configuration for a single host.
+type hostAuth struct {
+	header http.Header
+	// Authorization header value (Basic or Bearer).
+	authorization string
+}
+
+// NewAuth creates an empty Auth.
+func NewAuth() *Auth {
+	return &Auth{hosts: make(map[string]*hostAuth)}
+}
+
+// AddHeader sends name: value on every request to host.
+func (a *Auth) AddHeader(host, name, value string) {
+	a.host(host).header.Add(name, value)
+}
+
+// SetBasic sends HTTP basic credentials to host.
+func (a *Auth) SetBasic(host, user, password string) {
+	req := &http.Request{Header: make(http.Header)}
+	req.SetBasicAuth(user, password)
+	a.host(host).authorization = req.Header.Get("Authorization")
+}
+
+// SetBearer sends a bearer token to host.
+func (a *Auth) SetBearer(host, token string) {
+	a.host(host).authorization = "Bearer " + token
+}
+
+// Empty reports whether no headers, credentials or cookies are configured.
+func (a *Auth) Empty() bool {
+	return a == nil || (a.Jar == nil && len(a.hosts) == 0)
+}
+
+func (a *Auth) host(host string) *hostAuth {
+	host = strings.ToLower(host)
+	h, ok := a.hosts[host]
+	if !ok {
+		h = &hostAuth{header: make(http.Header)}
+		a.hosts[host] = h
+	}
+	return h
+}
+
+// lookup finds the configuration for a request host. An entry with an
+// explicit port only matches that port; one without matches any port.
+func (a *Auth) lookup(u *url.URL) *hostAuth {
+	if h, ok := a.hosts[strings.ToLower(u.Host)]; ok {
+		return h
+	}
+	return a.hosts[strings.ToLower(u.Hostname())]
+}
+
+// Apply adds the headers and credentials configured for req's host.
+func (a *Auth) Apply(req *http.Request) {
+	if a == nil {
+		return
+	}
+	h := a.lookup(req.URL)
+	if h == nil {
+		return
+	}
+	for name, values := range h.header {
+		req.Header[name] = append([]string(nil), values...)
+	}
+	if h.authorization != "" {
+		req.Header.Set("Authorization", h.authorization)
+	}
+}
+
+// Configure installs the cookie jar on client and a redirect policy that
+// strips every configured header before re-applying only those scoped to
+// the redirect target's host.
+func (a *Auth) Configure(client *http.Client) {
+	if a == nil {
+		return
+	}
+	if a.Jar != nil {
+		client.Jar = a.Jar
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		req.Header.Del("Authorization")
+		for _, h := range a.hosts {
+			for name := range h.header {
+				req.Header.Del(name)
+			}
+		}
+		a.Apply(req)
+		return nil
+	}
+}
+
+// LoadCookieFile reads a Netscape/Mozilla cookies.txt file (as exported by
+// browsers and curl) into a new cookie jar. Expired cookies are skipped.
+func (a *Auth) LoadCookieFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening cookie file: %w", err)
+	}
+	defer f.Close()
+
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return fmt.Errorf("creating cookie jar: %w", err)
+	}
+
+	now := time.Now()
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		httpOnly := false
+		if rest, ok := strings.CutPrefix(line, "#HttpOnly_"); ok {
+			line, httpOnly = rest, true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("%s:%d: expected 7 tab-separated fields, got %d", path, lineNo, len(fields))
+		}
+		domain, subdomains, cookiePath, secure, expiry, name, value :=
+			fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+
+		cookie := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     cookiePath,
+			Secure:   strings.EqualFold(secure, "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expiry != "" && expiry != "0" {
+			secs, err := strconv.ParseInt(expiry, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s:%d: invalid expiry %q", path, lineNo, expiry)
+			}
+			cookie.Expires = time.Unix(secs, 0)
+			if cookie.Expires.Before(now) {
+				continue
+			}
+		}
+
+		host := strings.TrimPrefix(domain, ".")
+		if strings.EqualFold(subdomains, "TRUE") {
+			cookie.Domain = host
+		}
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: "/"}, []*http.Cookie{cookie})
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading cookie file: %w", err)
+	}
+
+	a.Jar = jar
+	return nil
+}
+
+// SplitHostScope splits an optional "host=" prefix off a flag value, as in
+// "docs.example.com=user:pass". The prefix is only recognised when it looks
+// like a host name (contains a dot, is localhost, or has a port), so values
+// such as "X-Token: a=b" or padded tokens are left intact. Without a prefix
+// defaultHost is returned.
+func SplitHostScope(value, defaultHost string) (string, string) {
+	host, rest, ok := strings.Cut(value, "=")
+	if !ok || !looksLikeHost(host) {
+		return defaultHost, value
+	}
+	return host, rest
+}
+
+func looksLikeHost(s string) bool {
+	if s == "" || strings.ContainsAny(s, " /\t@") {
+		return false
+	}
+	name, port, err := net.SplitHostPort(s)
+	if err != nil {
+		name, port = s, ""
+	} else if _, err := strconv.Atoi(port); err != nil {
+		return false
+	}
+	if strings.Contains(name, ":") {
+		return false
+	}
+	return strings.Contains(name, ".") || strings.EqualFold(name, "localhost") || port != ""
+}
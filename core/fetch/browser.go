@@ -0,0 +1,327 @@
+// Package fetch — headless browser fetcher.
+// Renders pages in a locally installed headless Chromium over the DevTools
+// protocol, for single-page apps whose static HTML is an empty shell.
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/gaurav-prasanna/pagepipe/core"
+	"github.com/gaurav-prasanna/pagepipe/core/httpclient"
+)
+
+const (
+	// DefaultRenderWait bounds how long to wait for the page to settle
+	// after the load event.
+	DefaultRenderWait = 10 * time.Second
+
+	// networkIdleQuiet is how long the network must be silent to count
+	// as idle.
+	networkIdleQuiet = 500 * time.Millisecond
+
+	// minStaticText is the visible body text (in characters) below which
+	// static HTML is considered an unrendered shell.
+	minStaticText = 200
+)
+
+// BrowserFetcher renders pages with headless Chromium and returns the
+// resulting DOM. The browser is started on first use and reused until
+// Close is called.
+type BrowserFetcher struct {
+	// ExecPath is the Chromium/Chrome binary. Empty searches the usual
+	// install locations and $PATH.
+	ExecPath string
+	// WaitSelector, if set, is a CSS selector that must appear before the
+	// DOM is captured. Otherwise the fetcher waits for network idle.
+	WaitSelector string
+	// WaitTimeout bounds the wait after the load event.
+	WaitTimeout time.Duration
+	// ProxyServer, if set, is passed to Chromium's --proxy-server.
+	ProxyServer string
+	// UserAgent replaces Chromium's own user agent.
+	UserAgent string
+	// MaxBodySize is the largest rendered DOM accepted, in bytes.
+	MaxBodySize int64
+
+	mu            sync.Mutex
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
+	allocCancel   context.CancelFunc
+	launchErr     error // a failed launch is not retried
+}
+
+// NewBrowser creates a BrowserFetcher with default settings.
+func NewBrowser() *BrowserFetcher {
+	return &BrowserFetcher{
+		WaitTimeout: DefaultRenderWait,
+		UserAgent:   httpclient.DefaultUserAgent,
+		MaxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// Fetch navigates a new tab to url, waits for the page to render and
+// returns the serialized DOM.
+func (f *BrowserFetcher) Fetch(ctx context.Context, url string) (*core.FetchResult, error) {
+	browserCtx, err := f.browser()
+	if err != nil {
+		return nil, err
+	}
+
+	tabCtx, cancel := chromedp.NewContext(browserCtx)
+	defer cancel()
+	tabCtx, cancelTimeout := context.WithTimeout(tabCtx, defaultTimeout+f.WaitTimeout)
+	defer cancelTimeout()
+
+	// The tab hangs off the long-lived browser context, so propagate the
+	// caller's cancellation by hand.
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	tracker := newNetworkTracker()
+	chromedp.ListenTarget(tabCtx, tracker.handle)
+
+	start := time.Now()
+	resp, err := chromedp.RunResponse(tabCtx, chromedp.Navigate(url))
+	if err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", url, err)
+	}
+	if resp.Status < 200 || resp.Status >= 300 {
+		return nil, fmt.Errorf("unexpected status %d for %s", resp.Status, url)
+	}
+	// DevTools reports no MIME type for some documents; the page was
+	// rendered, so it is HTML.
+	mimeType := resp.MimeType
+	if mimeType == "" {
+		mimeType = "text/html"
+	}
+	if kind, mediaType, _ := classify(mimeType, "", nil); kind != core.ContentHTML {
+		return nil, &UnsupportedContentTypeError{URL: url, MediaType: mediaType}
+	}
+
+	if err := f.wait(tabCtx, tracker); err != nil {
+		return nil, fmt.Errorf("waiting for %s to render: %w", url, err)
+	}
+
+	var html string
+	if err := chromedp.Run(tabCtx, chromedp.OuterHTML("html", &html, chromedp.ByQuery)); err != nil {
+		return nil, fmt.Errorf("reading DOM of %s: %w", url, err)
+	}
+	if int64(len(html)) > f.MaxBodySize {
+		return nil, &BodyTooLargeError{URL: url, Limit: f.MaxBodySize}
+	}
+
+	// DevTools joins repeated headers with newlines.
+	header := make(http.Header, len(resp.Headers))
+	for k, v := range resp.Headers {
+		for _, line := range strings.Split(fmt.Sprint(v), "\n") {
+			header.Add(k, line)
+		}
+	}
+
+	return &core.FetchResult{
+		URL:           url,
+		FinalURL:      resp.URL,
+		RedirectChain: tracker.redirectChain(),
+		StatusCode:    int(resp.Status),
+		Header:        header,
+		ContentType:   mimeType,
+		Size:          int64(resp.EncodedDataLength),
+		Duration:      time.Since(start),
+		Kind:          core.ContentHTML,
+		HTML:          html,
+		Charset:       strings.ToLower(resp.Charset),
+	}, nil
+}
+
+// Close shuts down the browser, if it was started.
+func (f *BrowserFetcher) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.browserCancel != nil {
+		f.browserCancel()
+		f.allocCancel()
+		f.browserCtx, f.browserCancel, f.allocCancel = nil, nil, nil
+	}
+	return nil
+}
+
+// browser starts Chromium on first use and returns its context. A launch
+// failure is remembered, so a missing browser is not retried per page.
+func (f *BrowserFetcher) browser() (context.Context, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.browserCtx != nil {
+		return f.browserCtx, nil
+	}
+	if f.launchErr != nil {
+		return nil, f.launchErr
+	}
+
+	opts := append([]chromedp.ExecAllocatorOption{}, chromedp.DefaultExecAllocatorOptions[:]...)
+	if f.UserAgent != "" {
+		opts = append(opts, chromedp.UserAgent(f.UserAgent))
+	}
+	if f.ExecPath != "" {
+		opts = append(opts, chromedp.ExecPath(f.ExecPath))
+	}
+	if f.ProxyServer != "" {
+		opts = append(opts, chromedp.ProxyServer(f.ProxyServer))
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+	// Run with no actions to launch the browser and surface a missing
+	// binary here rather than on the first page.
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		f.launchErr = fmt.Errorf("starting headless browser (is Chromium installed?): %w", err)
+		return nil, f.launchErr
+	}
+
+	f.browserCtx, f.browserCancel, f.allocCancel = browserCtx, browserCancel, allocCancel
+	return browserCtx, nil
+}
+
+// wait blocks until WaitSelector is present, or the network is idle.
+// Hitting WaitTimeout while waiting for idle is not an error: long-polling
+// pages never go quiet, and their DOM is usually ready anyway.
+func (f *BrowserFetcher) wait(ctx context.Context, idle *networkTracker) error {
+	waitCtx, cancel := context.WithTimeout(ctx, f.WaitTimeout)
+	defer cancel()
+
+	if f.WaitSelector != "" {
+		return chromedp.Run(waitCtx, chromedp.WaitReady(f.WaitSelector, chromedp.ByQuery))
+	}
+
+	ticker := time.NewTicker(networkIdleQuiet / 5)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-waitCtx.Done():
+			return nil
+		case <-ticker.C:
+			if idle.idleFor() >= networkIdleQuiet {
+				return nil
+			}
+		}
+	}
+}
+
+// networkTracker counts in-flight requests from DevTools network events
+// and records redirects of the main document.
+type networkTracker struct {
+	mu         sync.Mutex
+	inflight   map[network.RequestID]bool
+	lastChange time.Time
+	redirects  []string
+}
+
+func newNetworkTracker() *networkTracker {
+	return &networkTracker{
+		inflight:   make(map[network.RequestID]bool),
+		lastChange: time.Now(),
+	}
+}
+
+func (t *networkTracker) handle(ev any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		t.inflight[e.RequestID] = true
+		if e.Type == network.ResourceTypeDocument && e.RedirectResponse != nil {
+			t.redirects = append(t.redirects, e.RedirectResponse.URL)
+		}
+	case *network.EventLoadingFinished:
+		delete(t.inflight, e.RequestID)
+	case *network.EventLoadingFailed:
+		delete(t.inflight, e.RequestID)
+	default:
+		return
+	}
+	t.lastChange = time.Now()
+}
+
+// redirectChain returns the document redirects seen so far.
+func (t *networkTracker) redirectChain() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.redirects...)
+}
+
+// idleFor returns how long there have been no requests in flight.
+func (t *networkTracker) idleFor() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.inflight) > 0 {
+		return 0
+	}
+	return time.Since(t.lastChange)
+}
+
+// AutoFetcher fetches pages statically and falls back to a browser when
+// the static HTML looks like an unrendered JavaScript shell.
+type AutoFetcher struct {
+	Static  core.Fetcher
+	Browser core.Fetcher
+	// OnFallback, if set, is called when rendering fails and the static
+	// HTML is returned instead.
+	OnFallback func(url string, err error)
+}
+
+// NewAuto creates an AutoFetcher.
+func NewAuto(static, browser core.Fetcher) *AutoFetcher {
+	return &AutoFetcher{Static: static, Browser: browser}
+}
+
+// Fetch tries the static fetcher first. If the result is HTML with almost
+// no visible text, the page is re-fetched through the browser; should that
+// fail, OnFallback is told and the static result is returned.
+func (f *AutoFetcher) Fetch(ctx context.Context, url string) (*core.FetchResult, error) {
+	result, err := f.Static.Fetch(ctx, url)
+	if err != nil || result.Kind != core.ContentHTML || !LooksEmpty(result.HTML) {
+		return result, err
+	}
+
+	rendered, err := f.Browser.Fetch(ctx, url)
+	if err != nil {
+		if f.OnFallback != nil {
+			f.OnFallback(url, err)
+		}
+		return result, nil
+	}
+	return rendered, nil
+}
+
+// spaMountSelectors match the root elements common SPA frameworks render into.
+var spaMountSelectors = "#root, #app, #__next, #__nuxt, #___gatsby, [data-reactroot], [ng-app], [ng-version], app-root"
+
+// LooksEmpty reports whether static HTML is likely an unrendered
+// single-page-app shell: little visible body text, plus either a known
+// framework mount point or scripts that would fill it in.
+func LooksEmpty(html string) bool {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return false
+	}
+
+	hasMount := doc.Find(spaMountSelectors).Length() > 0
+	hasScripts := doc.Find("script[src]").Length() > 0
+
+	body := doc.Find("body").Clone()
+	body.Find("script, style, noscript, template, svg").Remove()
+	text := strings.Join(strings.Fields(body.Text()), " ")
+
+	return len(text) < minStaticText && (hasMount || hasScripts)
+}
@@ -0,0 +1,83 @@
+// Package fetch — content type handling.
+// Classifies responses by media type so only documents the pipeline can
+// extract are returned, and everything else fails with a clear error.
+package fetch
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gaurav-prasanna/pagepipe/core"
+)
+
+// acceptHeader advertises every media type the pipeline can route,
+// preferring HTML.
+const acceptHeader = "text/html,application/xhtml+xml;q=0.9,text/markdown;q=0.8," +
+	"application/rss+xml;q=0.7,application/atom+xml;q=0.7,application/xml;q=0.6,text/xml;q=0.6,text/plain;q=0.5"
+
+// UnsupportedContentTypeError is returned when a response has a media type
+// the pipeline cannot extract (JSON APIs, PDFs, images, ...).
+type UnsupportedContentTypeError struct {
+	URL       string
+	MediaType string
+}
+
+func (e *UnsupportedContentTypeError) Error() string {
+	return fmt.Sprintf("unsupported content type %q for %s", e.MediaType, e.URL)
+}
+
+// BodyTooLargeError is returned when a response body exceeds MaxBodySize.
+type BodyTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("response body for %s exceeds %d bytes", e.URL, e.Limit)
+}
+
+// classify maps a response to a ContentKind. A missing or generic
+// Content-Type is resolved by sniffing the body; text/plain served from a
+// .md path is treated as Markdown.
+func classify(contentType string, urlPath string, body []byte) (core.ContentKind, string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		return core.ContentHTML, mediaType, true
+	case "text/markdown", "text/x-markdown":
+		return core.ContentMarkdown, mediaType, true
+	case "text/plain":
+		switch strings.ToLower(path.Ext(urlPath)) {
+		case ".md", ".markdown":
+			return core.ContentMarkdown, mediaType, true
+		}
+		return core.ContentText, mediaType, true
+	case "application/rss+xml", "application/atom+xml", "application/rdf+xml":
+		return core.ContentFeed, mediaType, true
+	case "application/xml", "text/xml":
+		// Generic XML is only accepted if it looks like a feed.
+		if looksLikeFeed(body) {
+			return core.ContentFeed, mediaType, true
+		}
+	}
+	return "", mediaType, false
+}
+
+// looksLikeFeed checks the start of an XML document for an RSS, RDF or
+// Atom root element.
+func looksLikeFeed(body []byte) bool {
+	head := body
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	s := string(head)
+	return strings.Contains(s, "<rss") || strings.Contains(s, "<rdf:RDF") ||
+		strings.Contains(s, "<feed")
+}
@@ -6,38 +6,70 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gaurav-prasanna/pagepipe/core"
+	"github.com/gaurav-prasanna/pagepipe/core/httpclient"
+	"golang.org/x/net/html/charset"
 )
 
 const (
-	defaultTimeout   = 30 * time.Second
-	defaultUserAgent = "PagePipe/1.0 (https://github.com/gaurav-prasanna/pagepipe)"
+	defaultTimeout = 30 * time.Second
+
+	// DefaultMaxBodySize caps how much of a response is read (10 MiB).
+	DefaultMaxBodySize int64 = 10 << 20
 )
 
 // HTTPFetcher fetches web pages via HTTP.
 type HTTPFetcher struct {
-	client *http.Client
+	// MaxBodySize is the largest response body accepted, in bytes.
+	MaxBodySize int64
+	// UserAgent is sent with every request.
+	UserAgent string
+	client    *http.Client
+	auth      *Auth
 }
 
-// New creates an HTTPFetcher with a sensible timeout.
+// New creates an HTTPFetcher with a sensible timeout and body size limit.
 func New() *HTTPFetcher {
 	return &HTTPFetcher{
-		client: &http.Client{Timeout: defaultTimeout},
+		MaxBodySize: DefaultMaxBodySize,
+		UserAgent:   httpclient.DefaultUserAgent,
+		client:      &http.Client{Timeout: defaultTimeout},
 	}
 }
 
-// Fetch retrieves the HTML content of the given URL.
+// UseTransport sends requests through rt, typically the shared transport
+// from package httpclient. Call it before UseAuth.
+func (f *HTTPFetcher) UseTransport(rt http.RoundTripper) {
+	f.client.Transport = rt
+}
+
+// UseAuth sends the cookies, headers and credentials configured in a with
+// every request, scoped to their hosts.
+func (f *HTTPFetcher) UseAuth(a *Auth) {
+	f.auth = a
+	a.Configure(f.client)
+}
+
+// Fetch retrieves the content of the given URL. Only HTML, plain text,
+// Markdown and RSS/Atom responses are accepted; anything else returns an
+// *UnsupportedContentTypeError, and bodies over MaxBodySize a
+// *BodyTooLargeError.
 func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (*core.FetchResult, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
-	req.Header.Set("User-Agent", defaultUserAgent)
-	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", f.UserAgent)
+	req.Header.Set("Accept", acceptHeader)
+	f.auth.Apply(req)
 
+	start := time.Now()
 	resp, err := f.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("fetching %s: %w", url, err)
@@ -48,14 +80,99 @@ func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (*core.FetchResult,
 		return nil, fmt.Errorf("unexpected status %d for %s", resp.StatusCode, url)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	if resp.ContentLength > f.MaxBodySize {
+		return nil, &BodyTooLargeError{URL: url, Limit: f.MaxBodySize}
+	}
+
+	// Read one byte past the limit to detect oversized bodies without
+	// trusting Content-Length.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.MaxBodySize+1))
 	if err != nil {
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
+	if int64(len(body)) > f.MaxBodySize {
+		return nil, &BodyTooLargeError{URL: url, Limit: f.MaxBodySize}
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	kind, mediaType, ok := classify(contentType, resp.Request.URL.Path, body)
+	if !ok {
+		return nil, &UnsupportedContentTypeError{URL: url, MediaType: mediaType}
+	}
+
+	html, encoding, err := decodeBody(body, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s body: %w", encoding, err)
+	}
 
 	return &core.FetchResult{
-		URL:        url,
-		StatusCode: resp.StatusCode,
-		HTML:       string(body),
+		URL:           url,
+		FinalURL:      resp.Request.URL.String(),
+		RedirectChain: redirectChain(resp),
+		StatusCode:    resp.StatusCode,
+		Header:        resp.Header,
+		ContentType:   contentType,
+		Size:          int64(len(body)),
+		Duration:      time.Since(start),
+		Kind:          kind,
+		HTML:          html,
+		Charset:       encoding,
 	}, nil
 }
+
+// redirectChain returns the URLs that redirected to the final response,
+// in request order. It is empty when no redirect was followed.
+func redirectChain(resp *http.Response) []string {
+	var chain []string
+	for r := resp.Request; r.Response != nil; r = r.Response.Request {
+		chain = append([]string{r.Response.Request.URL.String()}, chain...)
+	}
+	return chain
+}
+
+// decodeBody transcodes body to UTF-8. The encoding is determined, in order,
+// from a byte-order mark, the Content-Type charset parameter, the encoding
+// of an XML declaration (feeds), a <meta charset> or http-equiv declaration
+// in the first 1024 bytes, and finally a content sniff (defaulting to
+// windows-1252, as browsers do). It returns the decoded text and the
+// canonical name of the detected encoding.
+func decodeBody(body []byte, contentType string) (string, string, error) {
+	enc, name, _ := charset.DetermineEncoding(body, contentType)
+	if label := xmlEncoding(body, contentType); label != "" {
+		if e, n := charset.Lookup(label); e != nil {
+			enc, name = e, n
+		}
+	}
+	if name == "utf-8" {
+		// Already UTF-8: only strip a BOM if present.
+		return strings.TrimPrefix(string(body), "\uFEFF"), name, nil
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return "", name, err
+	}
+	return string(decoded), name, nil
+}
+
+// xmlDecl matches the encoding in an XML declaration at the start of a
+// document.
+var xmlDecl = regexp.MustCompile(`^\s*<\?xml\s[^>]*?encoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// xmlEncoding returns the encoding label declared by body's XML
+// declaration, unless the Content-Type header names a charset, which takes
+// precedence. A body starting with a byte-order mark never matches, so the
+// mark still wins.
+func xmlEncoding(body []byte, contentType string) string {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return ""
+	}
+	head := body
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if m := xmlDecl.FindSubmatch(head); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
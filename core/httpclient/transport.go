@@ -0,0 +1,189 @@
+// Package httpclient builds the HTTP transport shared by every outbound
+// request PagePipe makes: page fetches, sitemap discovery and embedding
+// calls. Proxy, TLS and connection-pool settings are configured once here.
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// DefaultUserAgent identifies PagePipe on every outbound request unless
+// overridden. Its product token ("PagePipe") is what robots.txt groups
+// are matched against.
+const DefaultUserAgent = "PagePipe/1.0 (https://github.com/gaurav-prasanna/pagepipe)"
+
+// Pool defaults, tuned for crawling a single site sequentially while
+// talking to a local embedding server.
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 8
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// Config describes how outbound connections are made. The zero value
+// behaves like http.DefaultTransport with larger per-host idle pools.
+type Config struct {
+	// ProxyURL routes requests through an HTTP(S) or SOCKS5 proxy.
+	// Empty uses the HTTP_PROXY / HTTPS_PROXY / NO_PROXY environment.
+	// Either way, loopback hosts (such as a local Ollama server) and hosts
+	// listed in NO_PROXY are reached directly.
+	ProxyURL string
+
+	// CAFile is a PEM bundle of extra trusted roots, added to the system pool.
+	CAFile string
+
+	// CertFile and KeyFile are a PEM client certificate for mutual TLS.
+	CertFile string
+	KeyFile  string
+
+	// InsecureHosts lists hosts (names or IPs) whose TLS certificates are
+	// not verified. Intended for test hosts with self-signed certificates;
+	// every other host is still verified, and it does not apply to
+	// connections tunnelled through a proxy.
+	InsecureHosts []string
+
+	// Connection pool tuning; zero values use the package defaults
+	// (MaxConnsPerHost zero means unlimited).
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+}
+
+// NewTransport builds an *http.Transport from cfg.
+func NewTransport(cfg Config) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	t.Proxy = http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", cfg.ProxyURL)
+		}
+		proxyFunc := (&httpproxy.Config{
+			HTTPProxy:  cfg.ProxyURL,
+			HTTPSProxy: cfg.ProxyURL,
+			NoProxy:    httpproxy.FromEnvironment().NoProxy,
+		}).ProxyFunc()
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	t.TLSClientConfig = tlsConfig
+	if len(cfg.InsecureHosts) > 0 {
+		t.DialTLSContext = insecureHostDialer(tlsConfig, cfg.InsecureHosts, t.TLSHandshakeTimeout)
+	}
+
+	t.MaxIdleConns = orDefault(cfg.MaxIdleConns, DefaultMaxIdleConns)
+	t.MaxIdleConnsPerHost = orDefault(cfg.MaxIdleConnsPerHost, DefaultMaxIdleConnsPerHost)
+	t.MaxConnsPerHost = cfg.MaxConnsPerHost
+	t.IdleConnTimeout = DefaultIdleConnTimeout
+	if cfg.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	return t, nil
+}
+
+// tlsConfig builds the client TLS configuration.
+func (cfg Config) tlsConfig() (*tls.Config, error) {
+	conf := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.CAFile)
+		}
+		conf.RootCAs = roots
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
+}
+
+// insecureHostDialer returns a DialTLSContext that skips certificate
+// verification for the listed hosts only. crypto/tls can only disable
+// verification per config, and the host being dialled is not visible to
+// its verification callbacks for IP addresses, so each direct connection
+// gets its own config instead. Since this dialer replaces the transport's
+// TLS dialing for every host, it enforces handshakeTimeout itself.
+func insecureHostDialer(base *tls.Config, hosts []string, handshakeTimeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	insecure := make(map[string]bool, len(hosts))
+	for _, h := range hosts {
+		insecure[strings.ToLower(h)] = true
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		conf := base.Clone()
+		conf.ServerName = host
+		conf.InsecureSkipVerify = insecure[strings.ToLower(host)]
+		conf.NextProtos = []string{"h2", "http/1.1"}
+
+		raw, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		conn := tls.Client(raw, conf)
+		hsCtx := ctx
+		if handshakeTimeout > 0 {
+			var cancel context.CancelFunc
+			hsCtx, cancel = context.WithTimeout(ctx, handshakeTimeout)
+			defer cancel()
+		}
+		if err := conn.HandshakeContext(hsCtx); err != nil {
+			raw.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+}
+
+// Client returns an http.Client using transport with the given timeout.
+// A nil transport uses http.DefaultTransport.
+func Client(transport http.RoundTripper, timeout time.Duration) *http.Client {
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+func orDefault(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestExplicitProxyBypassesLoopback(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		http.Error(w, "proxied", http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "direct")
+	}))
+	defer local.Close()
+
+	transport, err := NewTransport(Config{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := Client(transport, 0).Get(local.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "direct" || proxied.Load() != 0 {
+		t.Errorf("loopback request went through the proxy: status %d, body %q", resp.StatusCode, body)
+	}
+}
+
+func TestExplicitProxyRoutes(t *testing.T) {
+	t.Setenv("NO_PROXY", "internal.example")
+	const proxyURL = "http://proxy.example:3128"
+	transport, err := NewTransport(Config{ProxyURL: proxyURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url, want string
+	}{
+		{"https://docs.example.com/page", proxyURL},
+		{"http://docs.example.com/page", proxyURL},
+		{"http://localhost:11434/api/embed", ""},
+		{"http://127.0.0.1:11434/api/embed", ""},
+		{"https://internal.example/page", ""},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		got, err := transport.Proxy(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.url, err)
+		}
+		gotURL := ""
+		if got != nil {
+			gotURL = got.String()
+		}
+		if gotURL != tt.want {
+			t.Errorf("proxy for %s = %q, want %q", tt.url, gotURL, tt.want)
+		}
+	}
+}
@@ -2,15 +2,38 @@
 // Each stage of the pipeline is a clean, testable interface.
 package core
 
-import "context"
+import (
+	"context"
+	"net/http"
+	"time"
+)
 
 // FetchResult holds the raw HTML and response metadata from a fetch.
 type FetchResult struct {
-	URL        string
-	StatusCode int
-	HTML       string
+	URL           string   // URL as requested
+	FinalURL      string   // URL after following redirects
+	RedirectChain []string // URLs that redirected, in order (excludes FinalURL)
+	StatusCode    int
+	Header        http.Header
+	ContentType   string
+	Size          int64         // response body size in bytes, before transcoding
+	Duration      time.Duration // request start to body fully read
+	Kind          ContentKind   // how the body should be extracted
+	HTML          string        // response body, always UTF-8; only HTML when Kind is ContentHTML
+	Charset       string        // encoding the response was transcoded from (e.g. "shift_jis")
 }
 
+// ContentKind classifies a fetched document so the pipeline can route it
+// to a suitable extractor.
+type ContentKind string
+
+const (
+	ContentHTML     ContentKind = "html"
+	ContentText     ContentKind = "text"
+	ContentMarkdown ContentKind = "markdown"
+	ContentFeed     ContentKind = "feed" // RSS or Atom
+)
+
 // PageMetadata holds metadata extracted from the page and URL.
 type PageMetadata struct {
 	URL       string `json:"url"`
@@ -19,13 +42,83 @@ type PageMetadata struct {
 	Title     string `json:"title"`
 	Language  string `json:"language"`
 	FetchedAt string `json:"fetched_at"` // ISO8601
+
+	Description  string       `json:"description,omitempty"`
+	CanonicalURL string       `json:"canonical_url,omitempty"`
+	Author       string       `json:"author,omitempty"`
+	PublishedAt  string       `json:"published_at,omitempty"` // as declared by the page
+	ModifiedAt   string       `json:"modified_at,omitempty"`  // as declared by the page
+	Keywords     []string     `json:"keywords,omitempty"`
+	OpenGraph    *OpenGraph   `json:"open_graph,omitempty"`
+	Twitter      *TwitterCard `json:"twitter,omitempty"`
+	Article      *Article     `json:"article,omitempty"`
+	Breadcrumbs  []Breadcrumb `json:"breadcrumbs,omitempty"`
+	Fetch        *FetchInfo   `json:"fetch,omitempty"`
+}
+
+// FetchInfo records how the page was retrieved.
+type FetchInfo struct {
+	RequestedURL  string   `json:"requested_url"`
+	RedirectChain []string `json:"redirect_chain,omitempty"`
+	StatusCode    int      `json:"status_code"`
+	ContentType   string   `json:"content_type,omitempty"`
+	Charset       string   `json:"charset,omitempty"`
+	LastModified  string   `json:"last_modified,omitempty"`
+	ETag          string   `json:"etag,omitempty"`
+	Bytes         int64    `json:"bytes"`
+	DurationMS    int64    `json:"duration_ms"`
+}
+
+// OpenGraph holds the og:* properties declared by a page.
+type OpenGraph struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Image       string `json:"image,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+}
+
+// TwitterCard holds the twitter:* card properties declared by a page.
+type TwitterCard struct {
+	Card        string `json:"card,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	Site        string `json:"site,omitempty"`
+	Creator     string `json:"creator,omitempty"`
+}
+
+// Article holds the fields of a JSON-LD Article (or subtype) on the page.
+type Article struct {
+	Type          string   `json:"type"`
+	Headline      string   `json:"headline,omitempty"`
+	Authors       []string `json:"authors,omitempty"`
+	Publisher     string   `json:"publisher,omitempty"`
+	DatePublished string   `json:"date_published,omitempty"`
+	DateModified  string   `json:"date_modified,omitempty"`
+	Section       string   `json:"section,omitempty"`
+}
+
+// Breadcrumb is a single entry of a JSON-LD BreadcrumbList.
+type Breadcrumb struct {
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	URL      string `json:"url,omitempty"`
 }
 
-// Section represents a heading-delimited section of content.
+// JSONSchemaVersion is the version of the PageJSON layout. It changes
+// whenever a field is removed, renamed or changes meaning.
+const JSONSchemaVersion = 2
+
+// Section represents a heading-delimited section of content. Sections
+// under a heading of a deeper level are nested in Sections.
 type Section struct {
-	Heading string `json:"heading"`
-	Level   int    `json:"level"`
-	Text    string `json:"text"`
+	Heading  string    `json:"heading"`
+	Level    int       `json:"level"`
+	Text     string    `json:"text"` // plain text up to the next heading
+	Sections []Section `json:"sections,omitempty"`
 }
 
 // Heading represents a single heading found in the content.
@@ -40,27 +133,56 @@ type Link struct {
 	Href string `json:"href"`
 }
 
+// CodeBlock is a fenced or indented code block.
+type CodeBlock struct {
+	Language string `json:"language,omitempty"` // from the fence info string
+	Code     string `json:"code"`
+}
+
+// Table is a GFM table; cells are plain text.
+type Table struct {
+	Header []string   `json:"header"`
+	Rows   [][]string `json:"rows"`
+}
+
+// List is a bullet or ordered list.
+type List struct {
+	Ordered bool       `json:"ordered"`
+	Start   int        `json:"start,omitempty"` // first number of an ordered list
+	Items   []ListItem `json:"items"`
+}
+
+// ListItem is one list entry; lists nested in it are kept in Lists.
+type ListItem struct {
+	Text    string `json:"text"`
+	Checked *bool  `json:"checked,omitempty"` // set for task list items
+	Lists   []List `json:"lists,omitempty"`
+}
+
 // PageContent holds the text and structured content of a page.
 type PageContent struct {
 	Text     string    `json:"text"`
 	Markdown string    `json:"markdown"`
+	Lead     string    `json:"lead,omitempty"` // plain text before the first heading
 	Sections []Section `json:"sections"`
 }
 
-// PageStructure holds structural metadata parsed from the content.
+// PageStructure holds structural data parsed from the content, in
+// document order.
 type PageStructure struct {
-	Headings   []Heading `json:"headings"`
-	Links      []Link    `json:"links"`
-	CodeBlocks int       `json:"code_blocks"`
-	Tables     int       `json:"tables"`
-	Lists      int       `json:"lists"`
+	Headings   []Heading   `json:"headings"`
+	Links      []Link      `json:"links"`
+	CodeBlocks []CodeBlock `json:"code_blocks"`
+	Tables     []Table     `json:"tables"`
+	Lists      []List      `json:"lists"` // top-level lists; nested lists are inside their items
 }
 
 // PageJSON is the complete JSON output for a single page.
 type PageJSON struct {
-	Metadata  PageMetadata  `json:"metadata"`
-	Content   PageContent   `json:"content"`
-	Structure PageStructure `json:"structure"`
+	SchemaVersion int           `json:"schema_version"` // JSONSchemaVersion
+	Metadata      PageMetadata  `json:"metadata"`
+	Content       PageContent   `json:"content"`
+	Structure     PageStructure `json:"structure"`
 }
 
 // Fetcher retrieves raw HTML from a URL.
@@ -85,6 +207,13 @@ type Renderer interface {
 	Extension() string
 }
 
+// ContextRenderer is implemented by renderers that do network or other
+// long-running work, so a cancelled run can stop them mid-render.
+type ContextRenderer interface {
+	Renderer
+	RenderContext(ctx context.Context, markdown string, meta PageMetadata) ([]byte, error)
+}
+
 // Embedder generates a vector embedding for a text input.
 type Embedder interface {
 	Embed(ctx context.Context, text string, model string) ([]float64, error)
@@ -6,20 +6,37 @@ package normalize
 import (
 	"fmt"
 
-	htmltomarkdown "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/strikethrough"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/table"
 )
 
-// MarkdownNormalizer converts HTML to Markdown using html-to-markdown.
-type MarkdownNormalizer struct{}
+// MarkdownNormalizer converts HTML to GitHub Flavored Markdown using
+// html-to-markdown: CommonMark plus tables and strikethrough, which the
+// renderers parse back with goldmark's GFM extension.
+type MarkdownNormalizer struct {
+	conv *converter.Converter
+}
 
 // New creates a MarkdownNormalizer.
 func New() *MarkdownNormalizer {
-	return &MarkdownNormalizer{}
+	return &MarkdownNormalizer{
+		conv: converter.NewConverter(
+			converter.WithPlugins(
+				base.NewBasePlugin(),
+				commonmark.NewCommonmarkPlugin(),
+				table.NewTablePlugin(),
+				strikethrough.NewStrikethroughPlugin(),
+			),
+		),
+	}
 }
 
 // Normalize converts a cleaned HTML fragment into Markdown.
 func (n *MarkdownNormalizer) Normalize(html string) (string, error) {
-	markdown, err := htmltomarkdown.ConvertString(html)
+	markdown, err := n.conv.ConvertString(html)
 	if err != nil {
 		return "", fmt.Errorf("converting HTML to markdown: %w", err)
 	}
@@ -0,0 +1,16 @@
+// Package normalize — passthrough normalizer.
+// Used for documents that are already Markdown and need no conversion.
+package normalize
+
+// PassthroughNormalizer returns its input unchanged.
+type PassthroughNormalizer struct{}
+
+// NewPassthrough creates a PassthroughNormalizer.
+func NewPassthrough() *PassthroughNormalizer {
+	return &PassthroughNormalizer{}
+}
+
+// Normalize returns the Markdown as-is.
+func (n *PassthroughNormalizer) Normalize(markdown string) (string, error) {
+	return markdown, nil
+}
@@ -0,0 +1,179 @@
+// Package output — archive sink.
+// Streams every output file of a run into a single .zip or .tar.gz, which
+// is assembled in a temporary file and renamed into place on Close.
+package output
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// archiveExts are the recognised archive file extensions.
+var archiveExts = []string{".zip", ".tar.gz", ".tgz"}
+
+// ArchiveExt returns the archive extension of path (".zip", ".tar.gz" or
+// ".tgz"), or "" if it is not a supported archive name.
+func ArchiveExt(path string) string {
+	lower := strings.ToLower(path)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(lower, ext) {
+			return path[len(path)-len(ext):]
+		}
+	}
+	return ""
+}
+
+// archiveSink writes files into an archive. Entries cannot be replaced
+// once written, so each name may be stored only once.
+type archiveSink struct {
+	path    string
+	tmp     *os.File
+	modTime time.Time
+	names   map[string]bool
+
+	add    func(name string, data []byte) error
+	finish func() error // flushes the archive format writers
+}
+
+// NewArchive creates a Writer that streams into the archive at path; the
+// format follows the extension. policy decides what happens if the archive
+// file already exists (SuffixOnConflict picks name-1.zip, ...).
+func NewArchive(path string, policy Policy) (*Writer, error) {
+	ext := ArchiveExt(path)
+	if ext == "" {
+		return nil, fmt.Errorf("unsupported archive %s: use .zip, .tar.gz or .tgz", path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolving archive path: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating output directory: %w", err)
+	}
+
+	exists, err := fileExists(path)
+	if err != nil {
+		return nil, fmt.Errorf("checking %s: %w", path, err)
+	}
+	if exists {
+		switch policy {
+		case SkipExisting:
+			return nil, fmt.Errorf("%s: %w", path, ErrExists)
+		case SuffixOnConflict:
+			if path, err = freeName(strings.TrimSuffix(path, ext), ext, fileExists); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating archive: %w", err)
+	}
+	s := &archiveSink{path: path, tmp: tmp, modTime: time.Now(), names: make(map[string]bool)}
+
+	if strings.EqualFold(ext, ".zip") {
+		zw := zip.NewWriter(tmp)
+		s.add = func(name string, data []byte) error {
+			f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: s.modTime})
+			if err != nil {
+				return err
+			}
+			_, err = f.Write(data)
+			return err
+		}
+		s.finish = zw.Close
+	} else {
+		gz := gzip.NewWriter(tmp)
+		tw := tar.NewWriter(gz)
+		s.add = func(name string, data []byte) error {
+			hdr := &tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     name,
+				Mode:     0644,
+				Size:     int64(len(data)),
+				ModTime:  s.modTime,
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			_, err := tw.Write(data)
+			return err
+		}
+		s.finish = func() error {
+			if err := tw.Close(); err != nil {
+				return err
+			}
+			return gz.Close()
+		}
+	}
+
+	return &Writer{Policy: policy, sink: s}, nil
+}
+
+func (s *archiveSink) Exists(name string) (bool, error) {
+	return s.names[name], nil
+}
+
+func (s *archiveSink) Put(name string, data []byte) error {
+	if s.names[name] {
+		return fmt.Errorf("%s is already in the archive", name)
+	}
+	if err := s.add(name, data); err != nil {
+		return err
+	}
+	s.names[name] = true
+	return nil
+}
+
+func (s *archiveSink) Location(name string) string {
+	if name == "" {
+		return s.path
+	}
+	return s.path + ":" + name
+}
+
+// Close completes the archive and moves it into place.
+func (s *archiveSink) Close() error {
+	if s.tmp == nil {
+		return nil
+	}
+	tmp := s.tmp
+	s.tmp = nil
+	defer os.Remove(tmp.Name())
+
+	err := s.finish()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		return fmt.Errorf("writing archive %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Abort discards the partially written archive.
+func (s *archiveSink) Abort() {
+	if s.tmp == nil {
+		return
+	}
+	s.tmp.Close()
+	os.Remove(s.tmp.Name())
+	s.tmp = nil
+}
@@ -0,0 +1,95 @@
+// Package output — crawl manifest and table of contents.
+// A --all run records what happened to every discovered URL in
+// manifest.json, and can generate an index.md linking all outputs.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// ManifestFile is the crawl record written to the output directory root.
+	ManifestFile = "manifest.json"
+	// TOCFile is the optional generated table of contents.
+	TOCFile = "index.md"
+)
+
+// Page statuses recorded in the manifest.
+const (
+	StatusWritten   = "written"   // output file written
+	StatusExists    = "exists"    // output file already existed and was kept
+	StatusDuplicate = "duplicate" // same page as an earlier URL
+	StatusFailed    = "failed"    // fetch, extract, render or write failed
+	StatusPending   = "pending"   // not processed because the run was interrupted
+)
+
+// Manifest describes a --all run.
+type Manifest struct {
+	Source      string          `json:"source"`
+	Format      string          `json:"format"` // output file extension
+	StartedAt   time.Time       `json:"started_at"`
+	FinishedAt  time.Time       `json:"finished_at"`
+	Interrupted bool            `json:"interrupted,omitempty"`
+	Pages       []ManifestEntry `json:"pages"`
+}
+
+// ManifestEntry is the outcome for one discovered URL.
+type ManifestEntry struct {
+	URL         string `json:"url"`
+	FinalURL    string `json:"final_url,omitempty"`
+	Status      string `json:"status"`
+	Path        string `json:"path,omitempty"` // relative to the output directory
+	Title       string `json:"title,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentHash string `json:"content_hash,omitempty"` // SHA-256 of the Markdown
+	WordCount   int    `json:"word_count,omitempty"`
+	DurationMS  int64  `json:"duration_ms"`
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// WriteManifest writes m to ManifestFile. It is always replaced.
+func (w *Writer) WriteManifest(m *Manifest) (string, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding manifest: %w", err)
+	}
+	return w.replace(ManifestFile, append(data, '\n'))
+}
+
+// WriteTOC writes TOCFile: a Markdown list linking every output file in
+// the manifest, in crawl order. It is always replaced.
+func (w *Writer) WriteTOC(m *Manifest) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", escapeLinkText(m.Source))
+	for _, p := range m.Pages {
+		if p.Path == "" || (p.Status != StatusWritten && p.Status != StatusExists) {
+			continue
+		}
+		title := p.Title
+		if title == "" {
+			title = p.URL
+		}
+		fmt.Fprintf(&b, "- [%s](%s) — %s\n", escapeLinkText(title), linkPath(p.Path), p.URL)
+	}
+
+	return w.replace(TOCFile, []byte(b.String()))
+}
+
+// escapeLinkText escapes characters that would end a Markdown link label.
+func escapeLinkText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, "\n", " ").Replace(s)
+}
+
+// linkPath percent-encodes a relative file path for use as a link target.
+func linkPath(rel string) string {
+	segments := strings.Split(rel, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,192 @@
+// Package output — URL to file path mapping for --all mode.
+// Every URL gets a distinct, filesystem-safe relative path: query strings
+// become hash suffixes, directory-like URLs become index files, and paths
+// that differ only in case (or collide between file and directory) are
+// disambiguated so nothing is overwritten within a run.
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// IndexFile is the URL → file map written to the output directory root.
+const IndexFile = "urlmap.json"
+
+// maxSegment caps a single path component in bytes, leaving room for a
+// hash suffix and extension under the common 255-byte limit.
+const maxSegment = 150
+
+// reservedNames cannot be used as file names on Windows, with or without
+// an extension.
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// relPathForURL maps a URL to a slash-separated path relative to the
+// output directory, without extension:
+//
+//	/                   → index
+//	/docs               → docs
+//	/docs/              → docs/index
+//	/docs/intro         → docs/intro
+//	/page?id=1          → page-<hash of query>
+//	/a/../../etc/passwd → etc/passwd
+func relPathForURL(parsed *url.URL) string {
+	// Cleaning the escaped path resolves ".." without ever leaving the root.
+	escaped := parsed.EscapedPath()
+	dirLike := escaped == "" || strings.HasSuffix(escaped, "/")
+	cleaned := strings.Trim(path.Clean("/"+escaped), "/")
+
+	var segments []string
+	if cleaned != "" {
+		for _, seg := range strings.Split(cleaned, "/") {
+			if unescaped, err := url.PathUnescape(seg); err == nil {
+				seg = unescaped
+			}
+			segments = append(segments, sanitizeSegment(seg))
+		}
+	}
+	if dirLike || len(segments) == 0 {
+		segments = append(segments, "index")
+	}
+
+	if parsed.RawQuery != "" {
+		last := len(segments) - 1
+		segments[last] += "-" + shortHash(parsed.RawQuery)
+	}
+	return strings.Join(segments, "/")
+}
+
+// sanitizeSegment makes one path component safe on Linux, macOS and
+// Windows: separators and reserved characters become "_", trailing dots
+// and spaces are dropped, reserved device names are suffixed and overlong
+// names are truncated with a hash of the original.
+func sanitizeSegment(seg string) string {
+	var b strings.Builder
+	for _, ch := range seg {
+		switch {
+		case ch < 0x20 || ch == 0x7f || strings.ContainsRune(`/\<>:"|?*`, ch):
+			b.WriteByte('_')
+		default:
+			b.WriteRune(ch)
+		}
+	}
+	s := strings.TrimRight(b.String(), ". ")
+	if s == "" {
+		return "_"
+	}
+
+	stem, rest, _ := strings.Cut(s, ".")
+	if reservedNames[strings.ToUpper(stem)] {
+		s = stem + "_"
+		if rest != "" {
+			s += "." + rest
+		}
+	}
+
+	if len(s) > maxSegment {
+		cut := maxSegment
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut] + "-" + shortHash(seg)
+	}
+	return s
+}
+
+// shortHash returns the first 8 hex digits of the SHA-256 of s.
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:4])
+}
+
+// claim reserves rel+ext for rawURL and returns the path to use. Paths are
+// compared case-insensitively, since macOS and Windows file systems are.
+// A path taken by another URL, or needed as both a file and a directory,
+// gets a hash of the URL appended.
+func (w *Writer) claim(rawURL, rel, ext string) string {
+	w.initClaims()
+
+	segments := strings.Split(rel, "/")
+	// Directories: a segment already used as a file name is renamed.
+	for i := range len(segments) - 1 {
+		dir := strings.ToLower(strings.Join(segments[:i+1], "/"))
+		if owner, ok := w.claimed[dir]; ok && owner != "" {
+			segments[i] += "-" + shortHash(rawURL)
+			dir = strings.ToLower(strings.Join(segments[:i+1], "/"))
+		}
+		w.claimed[dir] = ""
+	}
+
+	file := strings.Join(segments, "/") + ext
+	if owner, ok := w.claimed[strings.ToLower(file)]; ok && owner != rawURL {
+		segments[len(segments)-1] += "-" + shortHash(rawURL)
+		file = strings.Join(segments, "/") + ext
+	}
+	w.claimed[strings.ToLower(file)] = rawURL
+	return file
+}
+
+// Reserve keeps name (relative to the output directory) from being used
+// for a page by WriteAll. IndexFile and ManifestFile are always reserved.
+func (w *Writer) Reserve(name string) {
+	w.initClaims()
+	w.claimed[strings.ToLower(name)] = name
+}
+
+func (w *Writer) initClaims() {
+	if w.claimed == nil {
+		w.claimed = map[string]string{
+			strings.ToLower(IndexFile):    IndexFile,
+			strings.ToLower(ManifestFile): ManifestFile,
+		}
+	}
+}
+
+// record notes that rawURL was stored as name.
+func (w *Writer) record(rawURL, name string) {
+	if w.index == nil {
+		w.index = make(map[string]string)
+	}
+	w.index[rawURL] = name
+}
+
+// Lookup returns the relative path WriteAll stored rawURL under.
+func (w *Writer) Lookup(rawURL string) (string, bool) {
+	name, ok := w.index[rawURL]
+	return name, ok
+}
+
+// WriteIndex writes the URL → file map of every page written (or skipped
+// as already existing) by WriteAll to IndexFile. It is always replaced.
+func (w *Writer) WriteIndex() (string, error) {
+	index := w.index
+	if index == nil {
+		index = map[string]string{}
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding URL index: %w", err)
+	}
+	return w.replace(IndexFile, append(data, '\n'))
+}
+
+// replace stores a run-level file (index, manifest, ...) regardless of
+// the overwrite policy and returns its location.
+func (w *Writer) replace(name string, data []byte) (string, error) {
+	if err := w.sink.Put(name, data); err != nil {
+		return "", fmt.Errorf("writing file %s: %w", w.sink.Location(name), err)
+	}
+	return w.sink.Location(name), nil
+}
@@ -0,0 +1,95 @@
+// Package output — storage sinks.
+// A Sink stores named files; the Writer decides the names. The directory
+// sink writes each file atomically under the output directory.
+package output
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Sink stores output files under slash-separated names relative to its
+// root.
+type Sink interface {
+	// Exists reports whether name is already stored.
+	Exists(name string) (bool, error)
+	// Put stores data as name. A directory replaces any previous
+	// content; an archive cannot, and returns an error for a name it
+	// already holds.
+	Put(name string, data []byte) error
+	// Location describes where name is stored, for messages.
+	Location(name string) string
+	// Close finalizes the sink.
+	Close() error
+}
+
+// dirSink stores files in a directory tree.
+type dirSink struct {
+	dir string
+}
+
+func (s *dirSink) path(name string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(name))
+}
+
+func (s *dirSink) Exists(name string) (bool, error) {
+	return fileExists(s.path(name))
+}
+
+func (s *dirSink) Put(name string, data []byte) error {
+	path := s.path(name)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", dir, err)
+	}
+	return writeAtomic(path, data)
+}
+
+func (s *dirSink) Location(name string) string {
+	return s.path(name)
+}
+
+func (s *dirSink) Close() error {
+	return nil
+}
+
+// fileExists reports whether path exists. Errors other than "not found",
+// such as a permission error, are returned rather than guessed at.
+func fileExists(path string) (bool, error) {
+	_, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// writeAtomic writes data to a temporary file in the target directory and
+// renames it over path, so readers (and a crash) only ever see the old
+// file or the complete new one.
+func writeAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Remove the temp file on any failure; after a successful rename
+	// this is a no-op.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,75 @@
+// Package output — NDJSON streaming.
+// Instead of files, a crawl can be written to a stream (usually stdout) as
+// one JSON record per page, emitted as soon as the page is processed.
+package output
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Record is one line of an NDJSON stream: the page's manifest entry plus
+// its rendered output.
+type Record struct {
+	ManifestEntry
+	Format string `json:"format"`
+	// Content is the rendered output: embedded as-is for JSON output, a
+	// string for text formats, and a base64 string (with Encoding set) for
+	// binary formats such as PDF. Absent unless Status is "written".
+	Content  json.RawMessage `json:"content,omitempty"`
+	Encoding string          `json:"encoding,omitempty"`
+}
+
+// Stream writes Records to w, one per line.
+type Stream struct {
+	w io.Writer
+}
+
+// NewStream creates a Stream writing to w.
+func NewStream(w io.Writer) *Stream {
+	return &Stream{w: w}
+}
+
+// Emit writes a record for entry with the rendered data (nil for pages
+// that were not written).
+func (s *Stream) Emit(entry ManifestEntry, format string, data []byte) error {
+	rec := Record{ManifestEntry: entry, Format: format}
+	if data != nil {
+		content, encoding, err := encodeContent(format, data)
+		if err != nil {
+			return err
+		}
+		rec.Content, rec.Encoding = content, encoding
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding record for %s: %w", entry.URL, err)
+	}
+	// One write per record, so concurrent readers never see partial lines.
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing record for %s: %w", entry.URL, err)
+	}
+	return nil
+}
+
+// encodeContent turns rendered output into a single-line JSON value.
+func encodeContent(format string, data []byte) (json.RawMessage, string, error) {
+	if format == ".json" && json.Valid(data) {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, data); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "", nil
+	}
+	if utf8.Valid(data) {
+		content, err := json.Marshal(string(data))
+		return content, "", err
+	}
+	content, err := json.Marshal(base64.StdEncoding.EncodeToString(data))
+	return content, "base64", err
+}
@@ -1,19 +1,45 @@
 // Package output handles file naming and writing for PagePipe outputs.
 // In --only mode, filenames are derived from the domain (e.g., example_com.md).
-// In --all mode, filenames mirror the URL path structure.
+// In --all mode, filenames mirror the URL path structure (see paths.go).
+//
+// Files are stored through a Sink — a directory or an archive — and an
+// existing file at the target path is handled according to the Writer's
+// Policy.
 package output
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
-// Writer writes rendered output to disk.
+// Policy decides what happens when the output file already exists.
+type Policy int
+
+const (
+	// Overwrite replaces the existing file (the default).
+	Overwrite Policy = iota
+	// SkipExisting leaves the existing file untouched.
+	SkipExisting
+	// SuffixOnConflict writes to name-1.ext, name-2.ext, ... instead.
+	SuffixOnConflict
+)
+
+// maxSuffix bounds the search for a free name under SuffixOnConflict.
+const maxSuffix = 10000
+
+// ErrExists is returned (wrapped) when SkipExisting left a file in place.
+var ErrExists = errors.New("output file already exists")
+
+// Writer names rendered outputs and stores them in a Sink.
 type Writer struct {
-	OutputDir string
+	Policy Policy
+
+	sink    Sink
+	claimed map[string]string // lower-cased relative path → owning URL ("" for directories)
+	index   map[string]string // URL → relative path written
 }
 
 // New creates a Writer targeting the given output directory.
@@ -32,49 +58,104 @@ func New(outputDir string) (*Writer, error) {
 		return nil, fmt.Errorf("creating output directory: %w", err)
 	}
 
-	return &Writer{OutputDir: outputDir}, nil
+	return &Writer{sink: &dirSink{dir: outputDir}}, nil
+}
+
+// Close finalizes the sink (for archives, writes and renames the file).
+func (w *Writer) Close() error {
+	return w.sink.Close()
+}
+
+// Location describes where the writer stores files: the output directory
+// or the archive file.
+func (w *Writer) Location() string {
+	return w.sink.Location("")
+}
+
+// Abort discards everything written to a sink that supports it (archives);
+// files already written to a directory are kept.
+func (w *Writer) Abort() {
+	if a, ok := w.sink.(interface{ Abort() }); ok {
+		a.Abort()
+	}
 }
 
 // WriteOnly writes output for --only mode.
 // Filename: domain_path.ext (e.g., example_com.md).
 func (w *Writer) WriteOnly(rawURL string, data []byte, ext string) (string, error) {
-	name := filenameFromURL(rawURL)
-	path := filepath.Join(w.OutputDir, name+ext)
-
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return "", fmt.Errorf("writing file %s: %w", path, err)
-	}
-	return path, nil
+	name, err := w.write(filenameFromURL(rawURL), ext, data)
+	return w.sink.Location(name), err
 }
 
 // WriteAll writes output for --all mode, mirroring the URL path structure.
 // Example: https://site.com/docs/intro → ./docs/intro.md
+// Distinct URLs never share a file within a run; the mapping is recorded
+// for WriteIndex.
 func (w *Writer) WriteAll(rawURL string, data []byte, ext string) (string, error) {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return "", fmt.Errorf("parsing URL: %w", err)
 	}
 
-	// Build the path from the URL.
-	urlPath := strings.TrimSuffix(parsed.Path, "/")
-	if urlPath == "" || urlPath == "/" {
-		urlPath = "/index"
+	rel := w.claim(rawURL, relPathForURL(parsed), ext)
+	name, err := w.write(strings.TrimSuffix(rel, ext), ext, data)
+	if err == nil || errors.Is(err, ErrExists) {
+		w.record(rawURL, name)
+	}
+	return w.sink.Location(name), err
+}
+
+// write resolves base+ext (slash-separated, relative to the sink) against
+// the overwrite policy and stores data there. It returns the name
+// actually used.
+func (w *Writer) write(base, ext string, data []byte) (string, error) {
+	name := base + ext
+
+	exists, err := w.exists(name)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		switch w.Policy {
+		case SkipExisting:
+			return name, fmt.Errorf("%s: %w", w.sink.Location(name), ErrExists)
+		case SuffixOnConflict:
+			free, err := freeName(base, ext, w.exists)
+			if err != nil {
+				return "", err
+			}
+			name = free
+		}
 	}
-	// Remove leading slash for filepath.Join.
-	urlPath = strings.TrimPrefix(urlPath, "/")
 
-	fullPath := filepath.Join(w.OutputDir, urlPath+ext)
+	if err := w.sink.Put(name, data); err != nil {
+		return "", fmt.Errorf("writing file %s: %w", w.sink.Location(name), err)
+	}
+	return name, nil
+}
 
-	// Ensure parent directories exist.
-	dir := filepath.Dir(fullPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("creating directory %s: %w", dir, err)
+// exists reports whether name is already stored in the sink.
+func (w *Writer) exists(name string) (bool, error) {
+	ok, err := w.sink.Exists(name)
+	if err != nil {
+		return false, fmt.Errorf("checking %s: %w", w.sink.Location(name), err)
 	}
+	return ok, nil
+}
 
-	if err := os.WriteFile(fullPath, data, 0644); err != nil {
-		return "", fmt.Errorf("writing file %s: %w", fullPath, err)
+// freeName returns the first base-N+ext for which exists is false.
+func freeName(base, ext string, exists func(string) (bool, error)) (string, error) {
+	for n := 1; n <= maxSuffix; n++ {
+		candidate := fmt.Sprintf("%s-%d%s", base, n, ext)
+		taken, err := exists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
 	}
-	return fullPath, nil
+	return "", fmt.Errorf("no free file name for %s%s after %d attempts", base, ext, maxSuffix)
 }
 
 // filenameFromURL converts a URL into a flat filename.
@@ -16,6 +16,7 @@ import (
 
 	"github.com/gaurav-prasanna/pagepipe/core"
 	"github.com/gaurav-prasanna/pagepipe/core/chunk"
+	"github.com/gaurav-prasanna/pagepipe/core/httpclient"
 )
 
 const (
@@ -27,6 +28,7 @@ const (
 type EmbeddingsRenderer struct {
 	Model     string
 	ChunkSize int
+	UserAgent string
 	client    *http.Client
 }
 
@@ -35,10 +37,17 @@ func NewEmbeddingsRenderer(model string, chunkSize int) *EmbeddingsRenderer {
 	return &EmbeddingsRenderer{
 		Model:     model,
 		ChunkSize: chunkSize,
+		UserAgent: httpclient.DefaultUserAgent,
 		client:    &http.Client{Timeout: embeddingTimeout},
 	}
 }
 
+// UseTransport sends embedding requests through rt, typically the shared
+// transport from package httpclient.
+func (r *EmbeddingsRenderer) UseTransport(rt http.RoundTripper) {
+	r.client.Transport = rt
+}
+
 // ollamaRequest is the request body for the Ollama embeddings API.
 type ollamaRequest struct {
 	Model  string `json:"model"`
@@ -53,6 +62,12 @@ type ollamaResponse struct {
 // Render chunks the Markdown, embeds each chunk, and produces
 // the human-readable .embeddings.txt output.
 func (r *EmbeddingsRenderer) Render(markdown string, meta core.PageMetadata) ([]byte, error) {
+	return r.RenderContext(context.Background(), markdown, meta)
+}
+
+// RenderContext is Render with a context that cancels in-flight
+// embedding requests.
+func (r *EmbeddingsRenderer) RenderContext(ctx context.Context, markdown string, meta core.PageMetadata) ([]byte, error) {
 	chunker := chunk.New(r.ChunkSize)
 	chunks := chunker.Chunk(markdown)
 
@@ -63,10 +78,17 @@ func (r *EmbeddingsRenderer) Render(markdown string, meta core.PageMetadata) ([]
 	var buf strings.Builder
 	// Write header.
 	fmt.Fprintf(&buf, "# source: %s\n", meta.URL)
+	writeHeaderField(&buf, "canonical", meta.CanonicalURL)
+	writeHeaderField(&buf, "title", meta.Title)
+	writeHeaderField(&buf, "description", meta.Description)
+	writeHeaderField(&buf, "author", meta.Author)
+	writeHeaderField(&buf, "published", meta.PublishedAt)
+	writeHeaderField(&buf, "modified", meta.ModifiedAt)
+	writeHeaderField(&buf, "language", meta.Language)
+	writeHeaderField(&buf, "keywords", strings.Join(meta.Keywords, ", "))
 	fmt.Fprintf(&buf, "# model: %s\n", r.Model)
 	fmt.Fprintf(&buf, "# chunk_size: %d\n\n", r.ChunkSize)
 
-	ctx := context.Background()
 	for i, chunkText := range chunks {
 		embedding, err := r.embed(ctx, chunkText)
 		if err != nil {
@@ -92,6 +114,16 @@ func (r *EmbeddingsRenderer) Extension() string {
 	return ".embeddings.txt"
 }
 
+// writeHeaderField writes a "# key: value" header line if value is set.
+func writeHeaderField(buf *strings.Builder, key, value string) {
+	if value == "" {
+		return
+	}
+	// Header lines must stay single-line.
+	value = strings.Join(strings.Fields(value), " ")
+	fmt.Fprintf(buf, "# %s: %s\n", key, value)
+}
+
 // embed calls the Ollama embedding API for a single text input.
 func (r *EmbeddingsRenderer) embed(ctx context.Context, text string) ([]float64, error) {
 	reqBody := ollamaRequest{
@@ -108,6 +140,7 @@ func (r *EmbeddingsRenderer) embed(ctx context.Context, text string) ([]float64,
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", r.UserAgent)
 
 	resp, err := r.client.Do(req)
 	if err != nil {
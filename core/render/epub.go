@@ -0,0 +1,401 @@
+// Package render — EPUB renderer.
+// Packages Markdown as a reflowable EPUB 3 book: each level-1 heading
+// starts a chapter (an XHTML content document), a nav document lists the
+// chapters and their sections, and the package metadata comes from
+// PageMetadata. A single page becomes a one-chapter book; a --bundle
+// becomes a book with a chapter per page.
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash/crc32"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/gaurav-prasanna/pagepipe/core"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// EPUBRenderer renders Markdown content as an EPUB 3 book.
+type EPUBRenderer struct {
+	md   goldmark.Markdown
+	code CodeOptions
+}
+
+// NewEPUBRenderer creates an EPUBRenderer.
+func NewEPUBRenderer() *EPUBRenderer {
+	r := &EPUBRenderer{code: DefaultCodeOptions()}
+	r.md = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithRendererOptions(
+			gmhtml.WithXHTML(),
+			renderer.WithNodeRenderers(
+				util.Prioritized(imageLinkRenderer{}, 100),
+				util.Prioritized(codeBlockRenderer{&r.code}, 100),
+			),
+		),
+	)
+	return r
+}
+
+// UseCodeOptions sets how code blocks are highlighted.
+func (r *EPUBRenderer) UseCodeOptions(opts CodeOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	r.code = opts
+	return nil
+}
+
+// Extension returns the file extension for EPUB output.
+func (r *EPUBRenderer) Extension() string {
+	return ".epub"
+}
+
+// epubChapter is one content document of the book.
+type epubChapter struct {
+	File     string
+	Title    string
+	Body     []byte
+	Sections []epubSection // level-2 headings, for the nav document
+}
+
+type epubSection struct {
+	ID    string
+	Title string
+}
+
+// epubFile is an entry of the EPUB container.
+type epubFile struct {
+	name string
+	data []byte
+}
+
+// Render converts Markdown into EPUB bytes.
+func (r *EPUBRenderer) Render(markdown string, meta core.PageMetadata) ([]byte, error) {
+	title := meta.Title
+	if title == "" {
+		title = meta.URL
+	}
+	lang := meta.Language
+	if lang == "" {
+		lang = "en"
+	}
+
+	parts := splitChapters(markdown)
+	chapters := make([]epubChapter, 0, len(parts))
+	for i, part := range parts {
+		ch, err := r.renderChapter(part, parts, i)
+		if err != nil {
+			return nil, err
+		}
+		if ch.Title == "" {
+			ch.Title = title
+		}
+		chapters = append(chapters, ch)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// The mimetype entry must come first, stored and without a data
+	// descriptor, so readers can identify the file from its first bytes.
+	mimetype := []byte("application/epub+zip")
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(mimetype),
+		CompressedSize64:   uint64(len(mimetype)),
+		UncompressedSize64: uint64(len(mimetype)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("writing EPUB: %w", err)
+	}
+	if _, err := w.Write(mimetype); err != nil {
+		return nil, fmt.Errorf("writing EPUB: %w", err)
+	}
+
+	files := []epubFile{
+		{"META-INF/container.xml", []byte(epubContainer)},
+		{"OEBPS/content.opf", epubPackage(meta, title, lang, chapters)},
+		{"OEBPS/nav.xhtml", epubNav(title, lang, chapters)},
+		{"OEBPS/style.css", []byte(epubCSS)},
+		{"OEBPS/title.xhtml", epubTitlePage(meta, title, lang)},
+	}
+	for _, ch := range chapters {
+		files = append(files, epubFile{"OEBPS/" + ch.File, xhtmlDocument(ch.Title, lang, ch.Body)})
+	}
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate})
+		if err != nil {
+			return nil, fmt.Errorf("writing EPUB: %w", err)
+		}
+		if _, err := w.Write(f.data); err != nil {
+			return nil, fmt.Errorf("writing EPUB: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("writing EPUB: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// chapterPart is the Markdown of one chapter plus the HTML anchors
+// (<a id="x"></a>) that pointed at it.
+type chapterPart struct {
+	markdown string
+	anchors  []string
+}
+
+// splitChapters splits Markdown at level-1 headings outside code blocks.
+// Anchor lines directly before a heading belong to its chapter.
+func splitChapters(markdown string) []chapterPart {
+	var parts []chapterPart
+	var cur chapterPart
+	var lines []string
+	var pending []string // anchors not yet followed by content
+	var fence string     // opening fence of the code block we are in, if any
+
+	flush := func() {
+		cur.markdown = strings.Join(lines, "\n")
+		if strings.TrimSpace(cur.markdown) != "" {
+			parts = append(parts, cur)
+		}
+		cur, lines = chapterPart{}, nil
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			fence = trimmed[:3]
+		case fence != "" && strings.HasPrefix(trimmed, fence):
+			fence = ""
+		}
+		if fence == "" {
+			if m := anchorLine.FindStringSubmatch(trimmed); m != nil {
+				pending = append(pending, m[1])
+				continue
+			}
+			if strings.HasPrefix(line, "# ") {
+				flush()
+			}
+		}
+		if trimmed != "" {
+			cur.anchors = append(cur.anchors, pending...)
+			pending = nil
+		}
+		lines = append(lines, line)
+	}
+	cur.anchors = append(cur.anchors, pending...)
+	flush()
+	return parts
+}
+
+// renderChapter converts one chapter's Markdown to XHTML. Links to anchors
+// in other chapters are pointed at those chapter files.
+func (r *EPUBRenderer) renderChapter(part chapterPart, parts []chapterPart, index int) (epubChapter, error) {
+	ch := epubChapter{File: chapterFile(index)}
+
+	targets := make(map[string]string)
+	for i, p := range parts {
+		for _, id := range p.anchors {
+			targets[id] = chapterFile(i)
+		}
+	}
+	source := []byte(part.markdown)
+	doc := r.md.Parser().Parse(text.NewReader(source))
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if link, ok := n.(*ast.Link); ok {
+			if id, found := bytes.CutPrefix(link.Destination, []byte("#")); found {
+				if file, ok := targets[string(id)]; ok {
+					link.Destination = []byte(file)
+				}
+			}
+			return ast.WalkContinue, nil
+		}
+		h, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		switch h.Level {
+		case 1:
+			if ch.Title == "" {
+				ch.Title = nodeText(h, source)
+			}
+		case 2:
+			id, _ := h.AttributeString("id")
+			idStr, _ := id.([]byte)
+			ch.Sections = append(ch.Sections, epubSection{ID: string(idStr), Title: nodeText(h, source)})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return ch, err
+	}
+
+	var body bytes.Buffer
+	if err := r.md.Renderer().Render(&body, source, doc); err != nil {
+		return ch, fmt.Errorf("rendering chapter %d: %w", index+1, err)
+	}
+	ch.Body = body.Bytes()
+	return ch, nil
+}
+
+func chapterFile(i int) string {
+	return fmt.Sprintf("chapter-%03d.xhtml", i+1)
+}
+
+// imageLinkRenderer renders images as links to their source: EPUB content
+// documents may not reference remote images, and images are not embedded.
+type imageLinkRenderer struct{}
+
+func (imageLinkRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindImage, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		img := n.(*ast.Image)
+		alt := nodeText(img, source)
+		if alt == "" {
+			alt = "image"
+		}
+		fmt.Fprintf(w, `<a class="image" href="%s">[%s]</a>`,
+			html.EscapeString(string(util.URLEscape(img.Destination, true))), html.EscapeString(alt))
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// --- Package documents ---
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+const epubCSS = `body { font-family: serif; line-height: 1.5; margin: 0 5%; }
+h1, h2, h3, h4, h5, h6 { font-family: sans-serif; line-height: 1.25; }
+pre { font-size: 0.85em; white-space: pre-wrap; background: #f5f5f5; padding: 0.5em; }
+code { font-family: monospace; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #999; padding: 0.25em 0.5em; }
+blockquote { margin-left: 1em; padding-left: 1em; border-left: 3px solid #ccc; }
+.meta { color: #555; font-size: 0.9em; }
+`
+
+// epubPackage builds content.opf: metadata, manifest and spine.
+func epubPackage(meta core.PageMetadata, title, lang string, chapters []epubChapter) []byte {
+	id := meta.CanonicalURL
+	if id == "" {
+		id = meta.URL
+	}
+	modified := time.Now().UTC()
+	if t, err := time.Parse(time.RFC3339, meta.FetchedAt); err == nil {
+		modified = t.UTC()
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="%s">`+"\n", esc(lang))
+	b.WriteString(`  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">` + "\n")
+	fmt.Fprintf(&b, "    <dc:identifier id=\"book-id\">urn:sha256:%x</dc:identifier>\n", sha256.Sum256([]byte(id)))
+	fmt.Fprintf(&b, "    <dc:title>%s</dc:title>\n", esc(title))
+	fmt.Fprintf(&b, "    <dc:language>%s</dc:language>\n", esc(lang))
+	if meta.Author != "" {
+		fmt.Fprintf(&b, "    <dc:creator>%s</dc:creator>\n", esc(meta.Author))
+	}
+	if meta.Description != "" {
+		fmt.Fprintf(&b, "    <dc:description>%s</dc:description>\n", esc(meta.Description))
+	}
+	if meta.PublishedAt != "" {
+		fmt.Fprintf(&b, "    <dc:date>%s</dc:date>\n", esc(meta.PublishedAt))
+	}
+	if meta.OpenGraph != nil && meta.OpenGraph.SiteName != "" {
+		fmt.Fprintf(&b, "    <dc:publisher>%s</dc:publisher>\n", esc(meta.OpenGraph.SiteName))
+	}
+	fmt.Fprintf(&b, "    <dc:source>%s</dc:source>\n", esc(meta.URL))
+	fmt.Fprintf(&b, "    <meta property=\"dcterms:modified\">%s</meta>\n", modified.Format("2006-01-02T15:04:05Z"))
+	b.WriteString("  </metadata>\n  <manifest>\n")
+	b.WriteString(`    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	b.WriteString(`    <item id="css" href="style.css" media-type="text/css"/>` + "\n")
+	b.WriteString(`    <item id="title" href="title.xhtml" media-type="application/xhtml+xml"/>` + "\n")
+	for i, ch := range chapters {
+		fmt.Fprintf(&b, "    <item id=\"ch%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, ch.File)
+	}
+	b.WriteString("  </manifest>\n  <spine>\n")
+	b.WriteString(`    <itemref idref="title"/>` + "\n")
+	for i := range chapters {
+		fmt.Fprintf(&b, "    <itemref idref=\"ch%d\"/>\n", i+1)
+	}
+	b.WriteString("  </spine>\n</package>\n")
+	return []byte(b.String())
+}
+
+// epubNav builds the navigation document: chapters with their sections.
+func epubNav(title, lang string, chapters []epubChapter) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<nav epub:type=\"toc\" id=\"toc\">\n<h1>%s</h1>\n<ol>\n", esc(title))
+	for _, ch := range chapters {
+		fmt.Fprintf(&b, "  <li><a href=\"%s\">%s</a>", ch.File, esc(ch.Title))
+		if len(ch.Sections) > 0 {
+			b.WriteString("\n    <ol>\n")
+			for _, s := range ch.Sections {
+				fmt.Fprintf(&b, "      <li><a href=\"%s#%s\">%s</a></li>\n", ch.File, esc(s.ID), esc(s.Title))
+			}
+			b.WriteString("    </ol>\n  ")
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ol>\n</nav>\n")
+	return xhtmlDocument(title, lang, []byte(b.String()))
+}
+
+// epubTitlePage shows the title, description, byline and source.
+func epubTitlePage(meta core.PageMetadata, title, lang string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", esc(title))
+	if meta.Description != "" {
+		fmt.Fprintf(&b, "<p>%s</p>\n", esc(meta.Description))
+	}
+	if byline := pdfByline(meta); byline != "" {
+		fmt.Fprintf(&b, "<p class=\"meta\">%s</p>\n", esc(byline))
+	}
+	fmt.Fprintf(&b, "<p class=\"meta\">Source: <a href=\"%s\">%s</a></p>\n", esc(meta.URL), esc(meta.URL))
+	return xhtmlDocument(title, lang, []byte(b.String()))
+}
+
+// xhtmlDocument wraps body in an XHTML5 content document.
+func xhtmlDocument(title, lang string, body []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<!DOCTYPE html>\n")
+	fmt.Fprintf(&b, `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%s" lang="%s">`+"\n", esc(lang), esc(lang))
+	fmt.Fprintf(&b, "<head>\n<meta charset=\"UTF-8\"/>\n<title>%s</title>\n", esc(title))
+	b.WriteString(`<link rel="stylesheet" type="text/css" href="style.css"/>` + "\n</head>\n<body>\n")
+	b.Write(body)
+	b.WriteString("</body>\n</html>\n")
+	return b.Bytes()
+}
+
+// esc escapes text for XML; html.EscapeString only emits entities that
+// XML also defines (or numeric references).
+func esc(s string) string {
+	return html.EscapeString(s)
+}
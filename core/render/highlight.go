@@ -0,0 +1,208 @@
+// Package render — syntax highlighting.
+// Code blocks in PDF, HTML and EPUB output are tokenized by chroma using
+// the fence language (```go) and colored with a chroma style. Blocks
+// without a language, or in a language chroma does not know, stay plain.
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// DefaultCodeStyle is the chroma style used to highlight code blocks.
+const DefaultCodeStyle = "github"
+
+// CodeOptions controls how code blocks are rendered.
+type CodeOptions struct {
+	// Style is a chroma style name; empty disables highlighting.
+	Style       string
+	LineNumbers bool
+}
+
+// DefaultCodeOptions returns highlighting in DefaultCodeStyle without
+// line numbers.
+func DefaultCodeOptions() CodeOptions {
+	return CodeOptions{Style: DefaultCodeStyle}
+}
+
+// CodeStyles returns the names of the available highlighting styles.
+func CodeStyles() []string {
+	return styles.Names()
+}
+
+// Validate reports an unknown style.
+func (o CodeOptions) Validate() error {
+	if o.Style == "" {
+		return nil
+	}
+	if o.style() == nil {
+		return fmt.Errorf("unknown code style %q (available: %s)", o.Style, strings.Join(CodeStyles(), ", "))
+	}
+	return nil
+}
+
+// style returns the chroma style named by Style, matched
+// case-insensitively, or nil if there is none. Validate and the
+// highlighter share this lookup so they never disagree on a name.
+func (o CodeOptions) style() *chroma.Style {
+	return styles.Registry[strings.ToLower(o.Style)]
+}
+
+// codeSpan is a run of code in one color and font style.
+type codeSpan struct {
+	text         string
+	color        chroma.Colour // unset for the block's text color
+	bold, italic bool          // only set when requested from highlight
+}
+
+// Colors of unhighlighted code blocks, also used when a style's background
+// is white and would not set the block apart from the page.
+const (
+	plainCodeText       = chroma.Colour(0x000000 + 1)
+	plainCodeBackground = chroma.Colour(0xf5f5f5 + 1)
+)
+
+// codeColors returns the text and background color of code blocks.
+func (o CodeOptions) codeColors() (text, background chroma.Colour) {
+	style := o.style()
+	if style == nil {
+		return plainCodeText, plainCodeBackground
+	}
+	bg := style.Get(chroma.Background)
+	text, background = bg.Colour, bg.Background
+	if !text.IsSet() {
+		text = plainCodeText
+	}
+	if !background.IsSet() || background == chroma.Colour(0xffffff+1) {
+		background = plainCodeBackground
+	}
+	return text, background
+}
+
+// highlight splits code into lines of colored spans. Without a style or a
+// lexer for lang, each line is a single span in the text color. With
+// fontStyles, spans are also marked bold or italic as the style says;
+// PDF output leaves it off, since the bundled monospace font has only a
+// regular face.
+func (o CodeOptions) highlight(code, lang string, fontStyles bool) [][]codeSpan {
+	code = strings.TrimSuffix(code, "\n")
+	var lexer chroma.Lexer
+	if o.style() != nil && lang != "" {
+		lexer = lexers.Get(lang)
+	}
+	if lexer != nil {
+		if it, err := chroma.Coalesce(lexer).Tokenise(nil, code); err == nil {
+			return o.spans(chroma.SplitTokensIntoLines(it.Tokens()), strings.Count(code, "\n")+1, fontStyles)
+		}
+	}
+	var lines [][]codeSpan
+	for _, line := range strings.Split(code, "\n") {
+		lines = append(lines, []codeSpan{{text: line}})
+	}
+	return lines
+}
+
+// spans converts token lines to span lines, keeping the first n lines
+// (lexers may add a final newline).
+func (o CodeOptions) spans(tokenLines [][]chroma.Token, n int, fontStyles bool) [][]codeSpan {
+	style := o.style()
+	lines := make([][]codeSpan, n)
+	for i := range min(n, len(tokenLines)) {
+		for _, tok := range tokenLines[i] {
+			text := strings.TrimRight(tok.Value, "\r\n")
+			if text == "" {
+				continue
+			}
+			span := codeSpan{text: text}
+			if strings.TrimSpace(text) != "" {
+				entry := style.Get(tok.Type)
+				span.color = entry.Colour
+				if fontStyles {
+					span.bold = entry.Bold == chroma.Yes
+					span.italic = entry.Italic == chroma.Yes
+				}
+			}
+			lines[i] = append(lines[i], span)
+		}
+	}
+	return lines
+}
+
+// codeBlock returns the source and fence language of a code block.
+func codeBlock(n ast.Node, source []byte) (code, lang string) {
+	if fenced, ok := n.(*ast.FencedCodeBlock); ok {
+		lang = string(fenced.Language(source))
+	}
+	return blockSource(n, source), lang
+}
+
+// codeBlockRenderer renders code blocks as highlighted HTML. Colors are set
+// in style attributes so they survive a replaced or missing stylesheet.
+type codeBlockRenderer struct {
+	options *CodeOptions
+}
+
+func (r codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.render)
+	reg.Register(ast.KindCodeBlock, r.render)
+}
+
+func (r codeBlockRenderer) render(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	opts := *r.options
+	code, lang := codeBlock(n, source)
+
+	w.WriteString("<pre")
+	if opts.Style != "" {
+		text, background := opts.codeColors()
+		fmt.Fprintf(w, ` style="color: %s; background-color: %s"`, text, background)
+	}
+	w.WriteString("><code")
+	if lang != "" {
+		fmt.Fprintf(w, ` class="language-%s"`, esc(lang))
+	}
+	w.WriteByte('>')
+	if code != "" {
+		lines := opts.highlight(code, lang, true)
+		digits := len(fmt.Sprint(len(lines)))
+		for i, line := range lines {
+			if opts.LineNumbers {
+				fmt.Fprintf(w, `<span style="opacity: 0.5; user-select: none">%*d  </span>`, digits, i+1)
+			}
+			for _, span := range line {
+				if css := span.css(); css != "" {
+					fmt.Fprintf(w, `<span style="%s">%s</span>`, css, esc(span.text))
+				} else {
+					w.WriteString(esc(span.text))
+				}
+			}
+			w.WriteByte('\n')
+		}
+	}
+	w.WriteString("</code></pre>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// css returns the inline style of a span, if any.
+func (s codeSpan) css() string {
+	var decls []string
+	if s.color.IsSet() {
+		decls = append(decls, "color: "+s.color.String())
+	}
+	if s.bold {
+		decls = append(decls, "font-weight: bold")
+	}
+	if s.italic {
+		decls = append(decls, "font-style: italic")
+	}
+	return strings.Join(decls, "; ")
+}
@@ -0,0 +1,37 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gaurav-prasanna/pagepipe/core"
+)
+
+func TestCodeStyleNameIsCaseInsensitive(t *testing.T) {
+	const markdown = "```go\nfunc main() {}\n```\n"
+	render := func(style string) string {
+		t.Helper()
+		r := NewHTMLRenderer()
+		if err := r.UseCodeOptions(CodeOptions{Style: style}); err != nil {
+			t.Fatalf("UseCodeOptions(%q): %v", style, err)
+		}
+		out, err := r.Render(markdown, core.PageMetadata{Title: "t"})
+		if err != nil {
+			t.Fatalf("Render: %v", err)
+		}
+		return string(out)
+	}
+
+	want := render("monokai")
+	if !strings.Contains(want, "background-color: #272822") {
+		t.Fatalf("monokai background missing from output:\n%s", want)
+	}
+	if want == render(DefaultCodeStyle) {
+		t.Fatal("monokai output matches the default style")
+	}
+	for _, name := range []string{"Monokai", "MONOKAI"} {
+		if got := render(name); got != want {
+			t.Errorf("style %q renders differently from %q", name, "monokai")
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Package render — HTML renderer.
+// Converts Markdown into a standalone HTML5 document for internal mirrors.
+// Raw HTML in the Markdown is dropped and unsafe link schemes are removed,
+// so the output is safe to serve. Headings get ids and a permalink, code
+// blocks are syntax-highlighted, and the page metadata goes into <head>.
+// The stylesheet is embedded, so the file has no external dependencies.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/gaurav-prasanna/pagepipe/core"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// DefaultHTMLStylesheet is the CSS embedded in HTML output unless
+// HTMLRenderer.Stylesheet is changed.
+const DefaultHTMLStylesheet = `body { max-width: 46rem; margin: 2rem auto; padding: 0 1rem; font: 16px/1.6 system-ui, -apple-system, "Segoe UI", sans-serif; color: #222; }
+h1, h2, h3, h4, h5, h6 { line-height: 1.25; margin: 1.6em 0 0.6em; }
+a { color: #1a5fb4; }
+.anchor { margin-left: 0.3em; color: #999; text-decoration: none; visibility: hidden; }
+h1:hover .anchor, h2:hover .anchor, h3:hover .anchor, h4:hover .anchor, h5:hover .anchor, h6:hover .anchor { visibility: visible; }
+pre { overflow-x: auto; padding: 0.75em 1em; background: #f5f5f5; border-radius: 4px; }
+code { font: 0.9em ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; }
+blockquote { margin: 1em 0; padding-left: 1em; color: #555; border-left: 3px solid #ccc; }
+img { max-width: 100%; }
+.meta { color: #666; font-size: 0.9em; }
+`
+
+// HTMLRenderer renders Markdown content as a standalone HTML5 document.
+type HTMLRenderer struct {
+	// Stylesheet is embedded in a <style> element; empty means unstyled.
+	Stylesheet string
+	md         goldmark.Markdown
+	code       CodeOptions
+}
+
+// NewHTMLRenderer creates an HTMLRenderer with the default stylesheet.
+func NewHTMLRenderer() *HTMLRenderer {
+	r := &HTMLRenderer{Stylesheet: DefaultHTMLStylesheet, code: DefaultCodeOptions()}
+	r.md = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+			parser.WithASTTransformers(util.Prioritized(anchorTransformer{}, 100)),
+		),
+		goldmark.WithRendererOptions(
+			renderer.WithNodeRenderers(
+				util.Prioritized(permalinkRenderer{}, 100),
+				util.Prioritized(codeBlockRenderer{&r.code}, 100),
+			),
+		),
+	)
+	return r
+}
+
+// UseCodeOptions sets how code blocks are highlighted.
+func (r *HTMLRenderer) UseCodeOptions(opts CodeOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	r.code = opts
+	return nil
+}
+
+// Extension returns the file extension for HTML output.
+func (r *HTMLRenderer) Extension() string {
+	return ".html"
+}
+
+// Render converts Markdown into HTML bytes.
+func (r *HTMLRenderer) Render(markdown string, meta core.PageMetadata) ([]byte, error) {
+	var body bytes.Buffer
+	if err := r.md.Convert([]byte(markdown), &body); err != nil {
+		return nil, fmt.Errorf("rendering HTML: %w", err)
+	}
+
+	title := meta.Title
+	if title == "" {
+		title = meta.URL
+	}
+	lang := meta.Language
+	if lang == "" {
+		lang = "en"
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html lang=\"%s\">\n<head>\n", esc(lang))
+	b.WriteString("<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", esc(title))
+	writeHTMLMeta(&b, meta)
+	if r.Stylesheet != "" {
+		// "</style" would end the element early.
+		css := strings.ReplaceAll(r.Stylesheet, "</", `<\/`)
+		fmt.Fprintf(&b, "<style>\n%s</style>\n", strings.TrimRight(css, "\n")+"\n")
+	}
+	b.WriteString("</head>\n<body>\n<main>\n<article>\n")
+	b.Write(body.Bytes())
+	b.WriteString("</article>\n</main>\n<footer class=\"meta\">\n")
+	if byline := pdfByline(meta); byline != "" {
+		fmt.Fprintf(&b, "<p>%s</p>\n", esc(byline))
+	}
+	fmt.Fprintf(&b, "<p>Source: <a href=\"%s\">%s</a></p>\n", esc(meta.URL), esc(meta.URL))
+	b.WriteString("</footer>\n</body>\n</html>\n")
+	return b.Bytes(), nil
+}
+
+// writeHTMLMeta writes the <head> elements describing the page. The
+// canonical link points at the original page, not the mirror.
+func writeHTMLMeta(b *bytes.Buffer, meta core.PageMetadata) {
+	metaTag := func(attr, key, value string) {
+		if value != "" {
+			fmt.Fprintf(b, "<meta %s=\"%s\" content=\"%s\">\n", attr, key, esc(value))
+		}
+	}
+
+	metaTag("name", "description", meta.Description)
+	metaTag("name", "author", meta.Author)
+	metaTag("name", "keywords", strings.Join(meta.Keywords, ", "))
+	metaTag("name", "generator", "pagepipe")
+	canonical := meta.CanonicalURL
+	if canonical == "" {
+		canonical = meta.URL
+	}
+	if canonical != "" {
+		fmt.Fprintf(b, "<link rel=\"canonical\" href=\"%s\">\n", esc(canonical))
+	}
+	if og := meta.OpenGraph; og != nil {
+		metaTag("property", "og:title", og.Title)
+		metaTag("property", "og:description", og.Description)
+		metaTag("property", "og:type", og.Type)
+		metaTag("property", "og:site_name", og.SiteName)
+		metaTag("property", "og:url", og.URL)
+	}
+	metaTag("property", "article:published_time", meta.PublishedAt)
+	metaTag("property", "article:modified_time", meta.ModifiedAt)
+}
+
+// anchorTransformer turns an HTML anchor paragraph (<a id="x"></a>, as
+// written by bundles) into the id of the block that follows it, since raw
+// HTML is not passed through.
+type anchorTransformer struct{}
+
+func (anchorTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	source := reader.Source()
+	var next ast.Node
+	for n := doc.FirstChild(); n != nil; n = next {
+		next = n.NextSibling()
+		p, ok := n.(*ast.Paragraph)
+		if !ok || p.Lines().Len() != 1 {
+			continue
+		}
+		line := p.Lines().At(0)
+		m := anchorLine.FindSubmatch(bytes.TrimSpace(line.Value(source)))
+		if m == nil {
+			continue
+		}
+		if next != nil {
+			next.SetAttributeString("id", append([]byte(nil), m[1]...))
+		}
+		doc.RemoveChild(doc, p)
+	}
+}
+
+// permalinkRenderer renders headings with a self-link after their text.
+type permalinkRenderer struct{}
+
+func (permalinkRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHeading, func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h := n.(*ast.Heading)
+		if entering {
+			fmt.Fprintf(w, "<h%d", h.Level)
+			if h.Attributes() != nil {
+				gmhtml.RenderAttributes(w, h, gmhtml.HeadingAttributeFilter)
+			}
+			w.WriteByte('>')
+			return ast.WalkContinue, nil
+		}
+		if id, ok := h.AttributeString("id"); ok {
+			if idBytes, ok := id.([]byte); ok {
+				fmt.Fprintf(w, `<a class="anchor" href="#%s" aria-label="Permalink">#</a>`, esc(string(idBytes)))
+			}
+		}
+		fmt.Fprintf(w, "</h%d>\n", h.Level)
+		return ast.WalkContinue, nil
+	})
+}
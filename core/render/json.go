@@ -1,56 +1,48 @@
 // Package render — JSON renderer.
 // Builds the structured JSON output from Markdown and page metadata.
-// Parses the Markdown to extract structural information (headings, links,
-// code blocks, tables, lists) without inferring any business-specific fields.
+// Parses the Markdown into a CommonMark/GFM syntax tree once and derives
+// structural information (headings, links, sections, code blocks, tables,
+// lists) from it, without inferring any business-specific fields.
 package render
 
 import (
 	"encoding/json"
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/gaurav-prasanna/pagepipe/core"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
 )
 
 // JSONRenderer produces structured JSON output from Markdown.
-type JSONRenderer struct{}
+type JSONRenderer struct {
+	text *TextRenderer
+}
 
 // NewJSONRenderer creates a JSONRenderer.
 func NewJSONRenderer() *JSONRenderer {
-	return &JSONRenderer{}
+	return &JSONRenderer{text: NewTextRenderer(0)}
 }
 
 // Render converts Markdown and metadata into the specified JSON structure.
 func (r *JSONRenderer) Render(markdown string, meta core.PageMetadata) ([]byte, error) {
-	headings := extractHeadings(markdown)
-	links := extractLinks(markdown)
-
-	// Build sections from headings.
-	sections := buildSections(markdown, headings)
-
-	// Count structural elements.
-	codeBlocks := countCodeBlocks(markdown)
-	tables := countTables(markdown)
-	lists := countLists(markdown)
-
-	// Strip markdown formatting to get plain text.
-	plainText := stripMarkdown(markdown)
+	source := []byte(markdown)
+	doc := r.text.md.Parser().Parse(text.NewReader(source))
 
+	lead, sections := buildSections(doc, source)
 	page := core.PageJSON{
-		Metadata: meta,
+		SchemaVersion: core.JSONSchemaVersion,
+		Metadata:      meta,
 		Content: core.PageContent{
-			Text:     plainText,
+			// Plain text, unwrapped.
+			Text:     strings.Join(textBlocks(doc, source, 0, true), "\n"),
 			Markdown: markdown,
+			Lead:     lead,
 			Sections: sections,
 		},
-		Structure: core.PageStructure{
-			Headings:   headings,
-			Links:      links,
-			CodeBlocks: codeBlocks,
-			Tables:     tables,
-			Lists:      lists,
-		},
+		Structure: extractStructure(doc, source),
 	}
 
 	data, err := json.MarshalIndent(page, "", "  ")
@@ -65,109 +57,170 @@ func (r *JSONRenderer) Extension() string {
 	return ".json"
 }
 
-// --- Markdown parsing helpers ---
-
-var headingRegex = regexp.MustCompile(`(?m)^(#{1,6})\s+(.+)$`)
-
-func extractHeadings(md string) []core.Heading {
-	matches := headingRegex.FindAllStringSubmatch(md, -1)
-	headings := make([]core.Heading, 0, len(matches))
-	for _, m := range matches {
-		headings = append(headings, core.Heading{
-			Level: len(m[1]),
-			Text:  strings.TrimSpace(m[2]),
-		})
+// --- Syntax tree helpers ---
+
+// extractStructure collects headings, links, code blocks, tables and lists
+// in document order. Lists nested in a list item are kept inside it.
+func extractStructure(doc ast.Node, source []byte) core.PageStructure {
+	s := core.PageStructure{
+		Headings:   []core.Heading{},
+		Links:      []core.Link{},
+		CodeBlocks: []core.CodeBlock{},
+		Tables:     []core.Table{},
+		Lists:      []core.List{},
 	}
-	return headings
+	listDepth := 0
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if list, ok := n.(*ast.List); ok {
+			if entering {
+				if listDepth == 0 {
+					s.Lists = append(s.Lists, listData(list, source))
+				}
+				listDepth++
+			} else {
+				listDepth--
+			}
+			return ast.WalkContinue, nil
+		}
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Heading:
+			s.Headings = append(s.Headings, core.Heading{Level: n.Level, Text: nodeText(n, source)})
+		case *ast.Link:
+			s.Links = append(s.Links, core.Link{Text: nodeText(n, source), Href: string(n.Destination)})
+			return ast.WalkSkipChildren, nil
+		case *ast.AutoLink:
+			href := string(n.URL(source))
+			s.Links = append(s.Links, core.Link{Text: string(n.Label(source)), Href: href})
+		case *ast.FencedCodeBlock:
+			s.CodeBlocks = append(s.CodeBlocks, core.CodeBlock{
+				Language: string(n.Language(source)),
+				Code:     blockSource(n, source),
+			})
+		case *ast.CodeBlock:
+			s.CodeBlocks = append(s.CodeBlocks, core.CodeBlock{Code: blockSource(n, source)})
+		case *east.Table:
+			// Walk on into the cells so links in them are collected too.
+			s.Tables = append(s.Tables, tableData(n, source))
+		}
+		return ast.WalkContinue, nil
+	})
+	return s
 }
 
-// linkRegex matches Markdown links [text](url).
-var linkRegex = regexp.MustCompile(`\[([^\]]*)\]\(([^)]+)\)`)
-
-func extractLinks(md string) []core.Link {
-	matches := linkRegex.FindAllStringSubmatch(md, -1)
-	links := make([]core.Link, 0, len(matches))
-	for _, m := range matches {
-		links = append(links, core.Link{
-			Text: m[1],
-			Href: m[2],
-		})
+// tableData converts a table to a header and rows of plain-text cells.
+func tableData(table *east.Table, source []byte) core.Table {
+	t := core.Table{Header: []string{}, Rows: [][]string{}}
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		cells := []string{}
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, nodeText(cell, source))
+		}
+		if _, ok := row.(*east.TableHeader); ok {
+			t.Header = cells
+		} else {
+			t.Rows = append(t.Rows, cells)
+		}
 	}
-	return links
+	return t
 }
 
-func buildSections(md string, headings []core.Heading) []core.Section {
-	if len(headings) == 0 {
-		return nil
+// listData converts a list; each item's text is the plain text of its
+// blocks other than nested lists, which become the item's Lists.
+func listData(list *ast.List, source []byte) core.List {
+	l := core.List{Ordered: list.IsOrdered(), Items: []core.ListItem{}}
+	if l.Ordered {
+		l.Start = list.Start
 	}
-
-	lines := strings.Split(md, "\n")
-	sections := make([]core.Section, 0, len(headings))
-	headingIdx := 0
-
-	var currentSection *core.Section
-	var sectionLines []string
-
-	for _, line := range lines {
-		if headingRegex.MatchString(line) && headingIdx < len(headings) {
-			// Flush previous section.
-			if currentSection != nil {
-				currentSection.Text = strings.TrimSpace(strings.Join(sectionLines, "\n"))
-				sections = append(sections, *currentSection)
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		var li core.ListItem
+		var text []string
+		for c := item.FirstChild(); c != nil; c = c.NextSibling() {
+			if nested, ok := c.(*ast.List); ok {
+				li.Lists = append(li.Lists, listData(nested, source))
+				continue
 			}
-			currentSection = &core.Section{
-				Heading: headings[headingIdx].Text,
-				Level:   headings[headingIdx].Level,
+			if block := textBlock(c, source, 0); len(block) > 0 {
+				if len(text) > 0 && !list.IsTight {
+					text = append(text, "")
+				}
+				text = append(text, block...)
 			}
-			sectionLines = nil
-			headingIdx++
-		} else if currentSection != nil {
-			sectionLines = append(sectionLines, line)
 		}
+		li.Text = strings.Join(text, "\n")
+		// The text of a task item starts with its rendered check box.
+		if first := item.FirstChild(); first != nil {
+			if box, ok := first.FirstChild().(*east.TaskCheckBox); ok {
+				checked := box.IsChecked
+				li.Checked = &checked
+				li.Text = strings.TrimPrefix(strings.TrimPrefix(li.Text, "[x] "), "[ ] ")
+			}
+		}
+		l.Items = append(l.Items, li)
 	}
-	// Flush last section.
-	if currentSection != nil {
-		currentSection.Text = strings.TrimSpace(strings.Join(sectionLines, "\n"))
-		sections = append(sections, *currentSection)
-	}
-
-	return sections
-}
-
-// countCodeBlocks counts fenced code blocks (``` delimited).
-func countCodeBlocks(md string) int {
-	return strings.Count(md, "```") / 2
-}
-
-// countTables counts Markdown tables by looking for separator rows (|---|).
-var tableRowRegex = regexp.MustCompile(`(?m)^\|[-:| ]+\|$`)
-
-func countTables(md string) int {
-	return len(tableRowRegex.FindAllString(md, -1))
+	return l
 }
 
-// countLists counts top-level list items (lines starting with - or * or 1.).
-var listItemRegex = regexp.MustCompile(`(?m)^[\s]*[-*]\s|^[\s]*\d+\.\s`)
-
-func countLists(md string) int {
-	return len(listItemRegex.FindAllString(md, -1))
-}
+// buildSections splits the document at headings and nests each section
+// under the closest preceding heading of a lower level. A section's text
+// is the plain text of its blocks up to the next heading of any level;
+// text before the first heading is returned as lead.
+func buildSections(doc ast.Node, source []byte) (string, []core.Section) {
+	var flat []core.Section
+	var parents []int // index into flat, or -1 for top-level sections
+	var lead []string
+	bodies := [][]string{}
+
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if h, ok := n.(*ast.Heading); ok {
+			parent := len(flat) - 1
+			for parent >= 0 && flat[parent].Level >= h.Level {
+				parent = parents[parent]
+			}
+			flat = append(flat, core.Section{Heading: nodeText(h, source), Level: h.Level})
+			parents = append(parents, parent)
+			bodies = append(bodies, nil)
+			continue
+		}
+		block := textBlock(n, source, 0)
+		if len(block) == 0 {
+			continue
+		}
+		body := &lead
+		if len(bodies) > 0 {
+			body = &bodies[len(bodies)-1]
+		}
+		if len(*body) > 0 {
+			*body = append(*body, "")
+		}
+		*body = append(*body, block...)
+	}
 
-// stripMarkdown removes common Markdown formatting to produce plain text.
-func stripMarkdown(md string) string {
-	text := md
-	// Remove headings markers.
-	text = headingRegex.ReplaceAllString(text, "$2")
-	// Remove bold/italic.
-	text = regexp.MustCompile(`\*{1,3}([^*]+)\*{1,3}`).ReplaceAllString(text, "$1")
-	// Remove links, keep text.
-	text = linkRegex.ReplaceAllString(text, "$1")
-	// Remove code block fences.
-	text = strings.ReplaceAll(text, "```", "")
-	// Remove inline code.
-	text = regexp.MustCompile("`([^`]+)`").ReplaceAllString(text, "$1")
-	// Collapse whitespace.
-	text = regexp.MustCompile(`\n{3,}`).ReplaceAllString(text, "\n\n")
-
-	return strings.TrimSpace(text)
+	children := make([][]int, len(flat))
+	var roots []int
+	for i, p := range parents {
+		flat[i].Text = strings.Join(bodies[i], "\n")
+		if p < 0 {
+			roots = append(roots, i)
+		} else {
+			children[p] = append(children[p], i)
+		}
+	}
+	var build func(i int) core.Section
+	build = func(i int) core.Section {
+		sec := flat[i]
+		for _, c := range children[i] {
+			sec.Sections = append(sec.Sections, build(c))
+		}
+		return sec
+	}
+	sections := []core.Section{}
+	for _, i := range roots {
+		sections = append(sections, build(i))
+	}
+	return strings.Join(lead, "\n"), sections
 }
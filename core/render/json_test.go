@@ -0,0 +1,87 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gaurav-prasanna/pagepipe/core"
+	"github.com/gaurav-prasanna/pagepipe/core/schema"
+)
+
+func TestJSONRendererMatchesSchema(t *testing.T) {
+	meta := core.PageMetadata{
+		URL:         "https://example.com/docs/start",
+		Domain:      "example.com",
+		Path:        "/docs/start",
+		Title:       "Getting started",
+		Language:    "en",
+		FetchedAt:   "2024-03-01T09:30:00Z",
+		Description: "How to install and run the tool.",
+		Keywords:    []string{"install", "usage"},
+	}
+
+	tests := []struct {
+		name     string
+		markdown string
+		links    []string // hrefs expected in structure.links
+	}{
+		{"headings", "# Title\n\nIntro.\n\n## Install\n\nRun it.\n\n### Linux\n\nUse apt.\n\n## Usage\n\nDone.\n", nil},
+		{"nested lists", "# Lists\n\n- one\n  - one.a\n  - one.b\n    1. deep\n- two\n\n1. first\n2. second\n", nil},
+		{"task items", "# Tasks\n\n- [x] done\n- [ ] todo\n  - [ ] nested todo\n", nil},
+		{"table", "# Table\n\n| Name | Value |\n|:-----|------:|\n| a | 1 |\n| b | [link](https://example.com) |\n", []string{"https://example.com"}},
+		{"fenced code", "# Code\n\n```go\nfunc main() {}\n```\n\n~~~\nplain\n~~~\n", nil},
+		{"indented code", "# Code\n\nText:\n\n    indented line\n    another\n", nil},
+		{"no headings", "Just a paragraph with *emphasis*, `code` and a [link](/x).\n\n> A quote.\n", []string{"/x"}},
+		{"empty", "", nil},
+	}
+
+	r := NewJSONRenderer()
+	s := schema.PageJSON()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := r.Render(tt.markdown, meta)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			errs, err := schema.Validate(s, data)
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			for _, e := range errs {
+				t.Errorf("schema violation: %v", e)
+			}
+			var page core.PageJSON
+			if err := json.Unmarshal(data, &page); err != nil {
+				t.Fatalf("decoding output: %v", err)
+			}
+			for _, href := range tt.links {
+				if !slices.ContainsFunc(page.Structure.Links, func(l core.Link) bool { return l.Href == href }) {
+					t.Errorf("link %s missing from structure.links %v", href, page.Structure.Links)
+				}
+			}
+			if t.Failed() {
+				t.Logf("output:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestPublishedSchemaIsCurrent(t *testing.T) {
+	want, err := json.MarshalIndent(schema.PageJSON(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, '\n')
+
+	got, err := os.ReadFile(filepath.Join("..", "..", "schema", "page.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("schema/page.schema.json is out of date; run go generate")
+	}
+}
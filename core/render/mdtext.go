@@ -0,0 +1,258 @@
+// Package render — Markdown to plain text.
+// Walks the Markdown AST and produces readable plain text: headings and
+// paragraphs as wrapped prose, lists with bullets and hanging indents,
+// block quotes and code indented, tables as aligned columns. Link and
+// image syntax is reduced to its text and raw HTML is dropped. Shared by
+// the text renderer, content.text and structure in JSON, and the PDF and
+// EPUB renderers for heading and cell text.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/util"
+)
+
+// minTextWidth keeps deeply nested blocks readable.
+const minTextWidth = 20
+
+// textBlocks renders the block children of n, separated by blank lines
+// when loose is set.
+func textBlocks(n ast.Node, source []byte, width int, loose bool) []string {
+	var lines []string
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		block := textBlock(c, source, width)
+		if len(block) == 0 {
+			continue
+		}
+		if loose && len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, block...)
+	}
+	return lines
+}
+
+// textBlock renders one block node as lines of at most width characters.
+func textBlock(n ast.Node, source []byte, width int) []string {
+	switch n := n.(type) {
+	case *ast.Heading, *ast.Paragraph, *ast.TextBlock:
+		return wrapText(strings.TrimSpace(inlineText(n, source, "\n")), width)
+
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		var lines []string
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			lines = append(lines, strings.TrimRight("    "+string(line.Value(source)), " \t\r\n"))
+		}
+		return lines
+
+	case *ast.Blockquote:
+		return indentLines(textBlocks(n, source, narrower(width, 2), true), "  ", "  ")
+
+	case *ast.List:
+		var lines []string
+		i := 0
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			marker := "• "
+			if n.IsOrdered() {
+				marker = fmt.Sprintf("%d. ", n.Start+i)
+			}
+			pad := strings.Repeat(" ", utf8.RuneCountInString(marker))
+			body := textBlocks(item, source, narrower(width, len(pad)), !n.IsTight)
+			if len(body) == 0 {
+				body = []string{""}
+			}
+			if !n.IsTight && len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, indentLines(body, marker, pad)...)
+			i++
+		}
+		return lines
+
+	case *east.Table:
+		return tableText(n, source)
+
+	case *ast.HTMLBlock, *ast.ThematicBreak:
+		return nil
+
+	default:
+		return textBlocks(n, source, width, true)
+	}
+}
+
+// tableText renders a table as space-separated columns under a dashed
+// header rule.
+func tableText(table *east.Table, source []byte) []string {
+	var rows [][]string
+	widths := make([]int, len(table.Alignments))
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			s := strings.TrimSpace(inlineText(cell, source, " "))
+			if i := len(cells); i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(s))
+			}
+			cells = append(cells, s)
+		}
+		rows = append(rows, cells)
+	}
+
+	format := func(cells []string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			var s string
+			if i < len(cells) {
+				s = cells[i]
+			}
+			gap := strings.Repeat(" ", w-utf8.RuneCountInString(s))
+			if table.Alignments[i] == east.AlignRight {
+				parts[i] = gap + s
+			} else {
+				parts[i] = s + gap
+			}
+		}
+		return strings.TrimRight(strings.Join(parts, "  "), " ")
+	}
+
+	var lines []string
+	for i, cells := range rows {
+		lines = append(lines, format(cells))
+		if _, ok := table.FirstChild().(*east.TableHeader); ok && i == 0 {
+			rule := make([]string, len(widths))
+			for j, w := range widths {
+				rule[j] = strings.Repeat("-", max(w, 1))
+			}
+			lines = append(lines, strings.Join(rule, "  "))
+		}
+	}
+	return lines
+}
+
+// inlineText returns the text of an inline container with Markdown syntax
+// removed: escapes and entities are resolved, links and images keep their
+// text, autolinks their URL, and raw HTML is dropped. Hard line breaks
+// become hardBreak, soft ones a space.
+func inlineText(n ast.Node, source []byte, hardBreak string) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			v := t.Segment.Value(source)
+			if !t.IsRaw() {
+				v = util.ResolveEntityNames(util.ResolveNumericReferences(util.UnescapePunctuations(v)))
+			}
+			b.Write(v)
+			switch {
+			case t.HardLineBreak():
+				b.WriteString(hardBreak)
+			case t.SoftLineBreak():
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(t.Value)
+		case *ast.CodeSpan:
+			for gc := t.FirstChild(); gc != nil; gc = gc.NextSibling() {
+				if s, ok := gc.(*ast.Text); ok {
+					b.Write(s.Segment.Value(source))
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.AutoLink:
+			b.Write(t.Label(source))
+			return ast.WalkSkipChildren, nil
+		case *east.TaskCheckBox:
+			if t.IsChecked {
+				b.WriteString("[x] ")
+			} else {
+				b.WriteString("[ ] ")
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// nodeText returns the plain text content of an inline container on a
+// single line.
+func nodeText(n ast.Node, source []byte) string {
+	return strings.TrimSpace(inlineText(n, source, " "))
+}
+
+// wrapText breaks each line of s at spaces so no line exceeds width
+// characters, unless a single word does. Width 0 disables wrapping.
+func wrapText(s string, width int) []string {
+	if s == "" {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if width <= 0 {
+			lines = append(lines, strings.TrimSpace(line))
+			continue
+		}
+		var cur bytes.Buffer
+		n := 0
+		for _, word := range strings.Fields(line) {
+			w := utf8.RuneCountInString(word)
+			if n > 0 && n+1+w > width {
+				lines = append(lines, cur.String())
+				cur.Reset()
+				n = 0
+			}
+			if n > 0 {
+				cur.WriteByte(' ')
+				n++
+			}
+			cur.WriteString(word)
+			n += w
+		}
+		lines = append(lines, cur.String())
+	}
+	return lines
+}
+
+// indentLines prefixes the first line with first and the others with
+// rest; blank lines stay blank.
+func indentLines(lines []string, first, rest string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			out[i] = strings.TrimRight(prefix, " ")
+		} else {
+			out[i] = prefix + line
+		}
+	}
+	return out
+}
+
+// narrower reduces a wrap width by an indent, keeping 0 (no wrapping).
+func narrower(width, indent int) int {
+	if width <= 0 {
+		return 0
+	}
+	return max(width-indent, minTextWidth)
+}
+
+// blockSource returns the raw lines of a code block.
+func blockSource(n ast.Node, source []byte) string {
+	var b strings.Builder
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		b.Write(line.Value(source))
+	}
+	return b.String()
+}
@@ -1,156 +1,824 @@
 // Package render — PDF renderer.
-// Converts Markdown into a styled PDF using gofpdf.
-// Handles headings (variable font sizes), paragraphs, code blocks, and lists.
+// Converts Markdown into a styled PDF using gofpdf, walking the CommonMark
+// syntax tree: headings (variable font sizes, with PDF bookmarks),
+// paragraphs with bold, italic, strikethrough, inline code and clickable
+// links, nested lists, block quotes, syntax-highlighted code blocks (see
+// highlight.go) and tables. HTML anchors (<a id="x"></a>) become link
+// destinations, so links to "#x" jump to them.
+// Text is set in an embedded Unicode TrueType font (see pdffont.go); page
+// geometry, headers, footers and the table of contents follow the
+// renderer's PDFLayout (see pdflayout.go).
 // Images are intentionally not rendered (v1 non-goal).
 package render
 
 import (
 	"bytes"
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/alecthomas/chroma/v2"
 	"github.com/gaurav-prasanna/pagepipe/core"
 	"github.com/jung-kurt/gofpdf"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
 )
 
 // PDFRenderer renders Markdown content as a PDF document.
-type PDFRenderer struct{}
+type PDFRenderer struct {
+	md     goldmark.Markdown
+	fonts  *pdfFonts
+	layout PDFLayout
+	code   CodeOptions
+}
 
-// NewPDFRenderer creates a PDFRenderer.
+// NewPDFRenderer creates a PDFRenderer using the bundled fonts and the
+// default layout.
 func NewPDFRenderer() *PDFRenderer {
-	return &PDFRenderer{}
+	return &PDFRenderer{
+		md:     goldmark.New(goldmark.WithExtensions(extension.GFM)),
+		fonts:  defaultPDFFonts(),
+		layout: DefaultPDFLayout(),
+		code:   DefaultCodeOptions(),
+	}
+}
+
+// UseLayout sets the page layout, after checking it can be rendered.
+func (r *PDFRenderer) UseLayout(layout PDFLayout) error {
+	if err := layout.Validate(); err != nil {
+		return fmt.Errorf("PDF layout: %w", err)
+	}
+	r.layout = layout
+	return nil
+}
+
+// UseCodeOptions sets how code blocks are highlighted.
+func (r *PDFRenderer) UseCodeOptions(opts CodeOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	r.code = opts
+	return nil
+}
+
+// UseFont sets body text in the TrueType font at path instead of the
+// bundled one. Bold and italic variants are picked up from the same
+// directory when they follow the "Name-Bold.ttf" convention.
+func (r *PDFRenderer) UseFont(path string) error {
+	fonts, err := loadPDFFonts(path)
+	if err != nil {
+		return err
+	}
+	r.fonts = fonts
+	return nil
 }
 
 // Render converts Markdown into PDF bytes.
 func (r *PDFRenderer) Render(markdown string, meta core.PageMetadata) ([]byte, error) {
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.SetAutoPageBreak(true, 15)
+	source := []byte(markdown)
+	doc := r.md.Parser().Parse(text.NewReader(source))
+
+	// The table of contents lists page numbers, which are only known once
+	// the document is laid out, so a first pass finds them. Both passes
+	// produce the same pages: the entries take the same space either way.
+	var pages []int
+	if r.layout.TOC {
+		pages = r.draw(doc, source, meta, nil).headingPages
+	}
+	w := r.draw(doc, source, meta, pages)
+
+	var buf bytes.Buffer
+	err := w.pdf.Output(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// draw lays out the document. tocPages holds the page of each table of
+// contents entry, or nil to leave them blank.
+func (r *PDFRenderer) draw(doc ast.Node, source []byte, meta core.PageMetadata, tocPages []int) *pdfWriter {
+	l := r.layout
+	width, height, _ := l.pageSize()
+	orientation := "P"
+	if l.Landscape {
+		orientation = "L"
+	}
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		OrientationStr: orientation,
+		UnitStr:        "mm",
+		Size:           gofpdf.SizeType{Wd: width, Ht: height},
+	})
+	r.fonts.register(pdf)
+	top, right, bottom, left := l.Margins[0], l.Margins[1], l.Margins[2], l.Margins[3]
+	pdf.SetMargins(left, top, right)
+	pdf.SetAutoPageBreak(true, bottom)
+
+	w := &pdfWriter{
+		pdf:         pdf,
+		source:      source,
+		size:        l.FontSize,
+		codeOptions: r.code,
+		left:        left,
+		right:       right,
+		anchors:     make(map[string]int),
+		bookmarks:   l.Outline,
+		outline:     newOutline(),
+	}
+	if l.Header && meta.Title != "" {
+		pdf.SetHeaderFuncMode(func() { w.header(meta.Title) }, true)
+	}
+	if l.PageNumbers {
+		pdf.AliasNbPages("")
+		pdf.SetFooterFunc(w.footer)
+	}
 	pdf.AddPage()
+	w.titleBlock(meta)
+
+	// Internal link destinations, created up front so links may precede
+	// their anchors.
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if id := w.anchorID(n); id != "" {
+			w.anchors[id] = pdf.AddLink()
+		}
+	}
+
+	if l.TOC {
+		var entries []tocEntry
+		_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if h, ok := n.(*ast.Heading); ok && entering && h.Level <= tocDepth {
+				entries = append(entries, tocEntry{text: nodeText(h, source), level: h.Level, link: pdf.AddLink()})
+			}
+			return ast.WalkContinue, nil
+		})
+		if len(entries) > 0 {
+			w.toc(entries, tocPages)
+			pdf.AddPage()
+		}
+	}
+
+	w.blocks(doc, false)
+	return w
+}
+
+// Extension returns the file extension for PDF output.
+func (r *PDFRenderer) Extension() string {
+	return ".pdf"
+}
+
+// pdfByline joins author and dates into a single "By X - Published Y" line.
+func pdfByline(meta core.PageMetadata) string {
+	var parts []string
+	if meta.Author != "" {
+		parts = append(parts, "By "+meta.Author)
+	}
+	if meta.PublishedAt != "" {
+		parts = append(parts, "Published "+meta.PublishedAt)
+	}
+	if meta.ModifiedAt != "" && meta.ModifiedAt != meta.PublishedAt {
+		parts = append(parts, "Updated "+meta.ModifiedAt)
+	}
+	return strings.Join(parts, " - ")
+}
 
-	// Title from metadata.
+// anchorLine matches an HTML anchor on a line of its own.
+var anchorLine = regexp.MustCompile(`^<a (?:id|name)="([^"]+)"></a>$`)
+
+// Layout constants, in mm and points. Font sizes and line heights are for
+// 10 pt body text and are scaled to PDFLayout.FontSize.
+const (
+	pdfBodySize    = 10.0 // body font size, pt
+	pdfLineHeight  = 5.0  // body line height
+	pdfBlockGap    = 3.0  // space after a block
+	pdfCodeSize    = 9.0
+	pdfCodeLine    = 4.5
+	pdfQuoteIndent = 6.0
+)
+
+// pdfHeadingSizes are the font sizes of heading levels 1-6.
+var pdfHeadingSizes = [...]float64{1: 18, 2: 15, 3: 13, 4: 12, 5: 11, 6: 10}
+
+// outline tracks the bookmark level of the previous heading, since PDF
+// outline entries may only nest one level deeper than their predecessor.
+type outline struct {
+	level int
+}
+
+func newOutline() *outline {
+	return &outline{level: -1}
+}
+
+// add bookmarks the current position under a heading of the given level.
+func (o *outline) add(pdf *gofpdf.Fpdf, text string, headingLevel int) {
+	level := headingLevel - 1
+	if level > o.level+1 {
+		level = o.level + 1
+	}
+	pdf.Bookmark(text, level, -1)
+	o.level = level
+}
+
+// tocEntry is a heading listed in the table of contents.
+type tocEntry struct {
+	text  string
+	level int
+	link  int // gofpdf link to the heading
+}
+
+// pdfWriter renders the Markdown syntax tree onto a PDF. Indentation of
+// lists and block quotes is applied through the left margin.
+type pdfWriter struct {
+	pdf         *gofpdf.Fpdf
+	source      []byte
+	size        float64        // body font size, pt
+	left, right float64        // page margins, before indentation
+	anchors     map[string]int // anchor id → gofpdf link
+	bookmarks   bool
+	outline     *outline
+	gray        bool // block quote text
+	codeOptions CodeOptions
+
+	tocLinks     []int // links of table of contents entries, in order
+	headingPages []int // page of each heading up to tocDepth, in order
+}
+
+// scale converts a size for 10 pt body text to the layout's font size.
+func (w *pdfWriter) scale(v float64) float64 {
+	return v * w.size / pdfBodySize
+}
+
+// lineHeight is the height of a line of body text.
+func (w *pdfWriter) lineHeight() float64 {
+	return w.scale(pdfLineHeight)
+}
+
+// titleBlock writes the title, description, source and byline.
+func (w *pdfWriter) titleBlock(meta core.PageMetadata) {
+	pdf := w.pdf
 	if meta.Title != "" {
-		pdf.SetFont("Helvetica", "B", 18)
-		pdf.MultiCell(0, 8, meta.Title, "", "L", false)
+		pdf.SetFont(fontBody, "B", w.scale(18))
+		pdf.MultiCell(0, w.scale(8), pdfText(meta.Title), "", "L", false)
 		pdf.Ln(4)
 	}
 
-	// Source URL.
-	pdf.SetFont("Helvetica", "I", 9)
+	// Description (summary line under the title).
+	if meta.Description != "" {
+		pdf.SetFont(fontBody, "", w.scale(11))
+		pdf.SetTextColor(60, 60, 60)
+		pdf.MultiCell(0, w.scale(5.5), pdfText(meta.Description), "", "L", false)
+		pdf.Ln(2)
+	}
+
+	// Source URL and byline.
+	pdf.SetFont(fontBody, "I", w.scale(9))
 	pdf.SetTextColor(100, 100, 100)
-	pdf.MultiCell(0, 5, "Source: "+meta.URL, "", "L", false)
+	pdf.MultiCell(0, w.lineHeight(), pdfText("Source: "+meta.URL), "", "L", false)
+	if meta.CanonicalURL != "" && meta.CanonicalURL != meta.URL {
+		pdf.MultiCell(0, w.lineHeight(), pdfText("Canonical: "+meta.CanonicalURL), "", "L", false)
+	}
+	if byline := pdfByline(meta); byline != "" {
+		pdf.MultiCell(0, w.lineHeight(), pdfText(byline), "", "L", false)
+	}
 	pdf.SetTextColor(0, 0, 0)
 	pdf.Ln(6)
+}
 
-	// Parse and render Markdown line by line.
-	lines := strings.Split(markdown, "\n")
-	inCodeBlock := false
+// toc writes the table of contents: one linked line per heading, indented
+// by level, with its page number (blank when pages is nil).
+func (w *pdfWriter) toc(entries []tocEntry, pages []int) {
+	pdf := w.pdf
+	top := tocDepth
+	for _, e := range entries {
+		top = min(top, e.level)
+	}
+	pdf.SetFont(fontBody, "B", w.scale(15))
+	pdf.SetTextColor(0, 0, 0)
+	pdf.MultiCell(0, w.scale(9), "Contents", "", "L", false)
+	pdf.Ln(2)
 
-	for i := 0; i < len(lines); i++ {
-		line := lines[i]
+	width, _ := pdf.GetPageSize()
+	avail := width - w.left - w.right
+	numWidth := w.scale(12)
+	for i, e := range entries {
+		style := ""
+		if e.level == top {
+			style = "B"
+		}
+		pdf.SetFont(fontBody, style, w.size)
+		indent := float64(e.level-top) * 6
+		label := w.fit(pdfText(e.text), avail-indent-numWidth-2)
+		page := ""
+		if i < len(pages) {
+			page = strconv.Itoa(pages[i])
+		}
+		pdf.SetX(w.left + indent)
+		pdf.CellFormat(avail-indent-numWidth, w.scale(6), label, "", 0, "L", false, e.link, "")
+		pdf.CellFormat(numWidth, w.scale(6), page, "", 1, "R", false, e.link, "")
+		w.tocLinks = append(w.tocLinks, e.link)
+	}
+}
 
-		// Toggle code block state.
-		if strings.HasPrefix(strings.TrimSpace(line), "```") {
-			inCodeBlock = !inCodeBlock
-			if inCodeBlock {
-				pdf.Ln(2)
-				pdf.SetFont("Courier", "", 9)
-				pdf.SetFillColor(245, 245, 245)
-			} else {
-				pdf.Ln(2)
-			}
-			continue
+// fit shortens s with an ellipsis to fit in width at the current font.
+func (w *pdfWriter) fit(s string, width float64) string {
+	if w.pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	lines := w.pdf.SplitText(s, width-w.pdf.GetStringWidth("…"))
+	if len(lines) == 0 {
+		return "…"
+	}
+	return strings.TrimRight(lines[0], " ") + "…"
+}
+
+// header writes the running header, the page title above a rule, on every
+// page but the first, which starts with the full title.
+func (w *pdfWriter) header(title string) {
+	pdf := w.pdf
+	if pdf.PageNo() == 1 {
+		return
+	}
+	_, top, _, _ := pdf.GetMargins()
+	width, _ := pdf.GetPageSize()
+	pdf.SetFont(fontBody, "I", w.scale(8))
+	pdf.SetTextColor(120, 120, 120)
+	pdf.SetXY(w.left, top-8)
+	pdf.CellFormat(width-w.left-w.right, 4, w.fit(pdfText(title), width-w.left-w.right), "", 0, "L", false, 0, "")
+	pdf.SetDrawColor(200, 200, 200)
+	pdf.Line(w.left, top-3, width-w.right, top-3)
+}
+
+// footer writes "Page N of M" centered in the bottom margin.
+func (w *pdfWriter) footer() {
+	pdf := w.pdf
+	_, _, _, bottom := pdf.GetMargins()
+	width, height := pdf.GetPageSize()
+	pdf.SetFont(fontBody, "", w.scale(8))
+	pdf.SetTextColor(120, 120, 120)
+	pdf.SetXY(w.left, height-bottom/2-2)
+	pdf.CellFormat(width-w.left-w.right, 4, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+}
+
+// heading writes a heading, moving to a new page first unless a couple of
+// lines fit below it, and records it for the outline and contents.
+func (w *pdfWriter) heading(text string, level int) {
+	pdf := w.pdf
+	size := w.scale(pdfHeadingSizes[level])
+	pdf.Ln(4)
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+size*0.6+2+2*w.lineHeight() > pageHeight-bottom {
+		pdf.AddPage()
+	}
+
+	if w.bookmarks {
+		w.outline.add(pdf, text, level)
+	}
+	if level <= tocDepth {
+		if i := len(w.headingPages); i < len(w.tocLinks) {
+			pdf.SetLink(w.tocLinks[i], pdf.GetY(), -1)
 		}
+		w.headingPages = append(w.headingPages, pdf.PageNo())
+	}
+	pdf.SetFont(fontBody, "B", size)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.MultiCell(0, size*0.6, pdfText(text), "", "L", false)
+	pdf.Ln(2)
+}
+
+// anchorID returns the id of a paragraph that is only an HTML anchor.
+func (w *pdfWriter) anchorID(n ast.Node) string {
+	p, ok := n.(*ast.Paragraph)
+	if !ok || p.Lines().Len() != 1 {
+		return ""
+	}
+	line := p.Lines().At(0)
+	if m := anchorLine.FindSubmatch(bytes.TrimSpace(line.Value(w.source))); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// blocks renders the block children of parent. In tight lists, paragraphs
+// are not followed by a gap.
+func (w *pdfWriter) blocks(parent ast.Node, tight bool) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		w.block(n, tight)
+	}
+}
 
-		if inCodeBlock {
-			// Render code lines with monospace font and background.
-			pdf.SetFont("Courier", "", 9)
-			pdf.SetFillColor(245, 245, 245)
-			pdf.MultiCell(0, 4.5, line, "", "L", true)
-			continue
+func (w *pdfWriter) block(n ast.Node, tight bool) {
+	pdf := w.pdf
+	switch n := n.(type) {
+	case *ast.Heading:
+		w.heading(nodeText(n, w.source), n.Level)
+
+	case *ast.Paragraph:
+		// Anchors mark a link destination and produce no output.
+		if id := w.anchorID(n); id != "" {
+			pdf.SetLink(w.anchors[id], pdf.GetY(), -1)
+			return
+		}
+		w.inline(n)
+		if !tight {
+			pdf.Ln(pdfBlockGap)
+		}
+
+	case *ast.TextBlock:
+		w.inline(n)
+
+	case *ast.List:
+		w.list(n)
+		if !tight {
+			pdf.Ln(pdfBlockGap)
 		}
 
-		// Skip empty lines (add spacing instead).
-		if strings.TrimSpace(line) == "" {
-			pdf.Ln(3)
-			continue
+	case *ast.Blockquote:
+		left, _, _, _ := pdf.GetMargins()
+		page, top := pdf.PageNo(), pdf.GetY()
+		pdf.SetLeftMargin(left + pdfQuoteIndent)
+		pdf.SetX(left + pdfQuoteIndent)
+		gray := w.gray
+		w.gray = true
+		w.blocks(n, false)
+		w.gray = gray
+		pdf.SetLeftMargin(left)
+		if bottom := pdf.GetY() - pdfBlockGap; pdf.PageNo() == page && bottom > top {
+			pdf.SetDrawColor(200, 200, 200)
+			pdf.SetLineWidth(0.8)
+			pdf.Line(left+1.5, top, left+1.5, bottom)
+			pdf.SetLineWidth(0.2)
+			pdf.SetDrawColor(0, 0, 0)
+		}
+		pdf.SetX(left)
+
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		w.code(n)
+		pdf.Ln(pdfBlockGap)
+
+	case *east.Table:
+		w.table(n)
+		pdf.Ln(pdfBlockGap)
+
+	case *ast.ThematicBreak:
+		left, _, right, _ := pdf.GetMargins()
+		width, _ := pdf.GetPageSize()
+		y := pdf.GetY() + 1
+		pdf.SetDrawColor(180, 180, 180)
+		pdf.Line(left, y, width-right, y)
+		pdf.SetDrawColor(0, 0, 0)
+		pdf.Ln(pdfBlockGap + 2)
+
+	case *ast.HTMLBlock:
+		// Raw HTML is not rendered.
+
+	default:
+		w.blocks(n, tight)
+	}
+}
+
+// code renders a code block on a filled background, highlighted by its
+// fence language. Lines longer than the block wrap, and each continuation
+// row starts with a "↪" marker; line numbers sit in a gutter when enabled.
+func (w *pdfWriter) code(n ast.Node) {
+	pdf := w.pdf
+	code, lang := codeBlock(n, w.source)
+	lines := w.codeOptions.highlight(code, lang, false)
+	textColor, background := w.codeOptions.codeColors()
+
+	pdf.SetFont(fontMono, "", w.scale(pdfCodeSize))
+	lineHeight := w.scale(pdfCodeLine)
+	charWidth := pdf.GetStringWidth("0")
+	left, _, right, bottom := pdf.GetMargins()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	const pad = 1.5
+	gutter := 0.0
+	if w.codeOptions.LineNumbers {
+		gutter = float64(len(strconv.Itoa(len(lines)))+1) * charWidth
+	}
+	textX := left + pad + gutter
+	cols := max(int((pageWidth-right-pad-textX)/charWidth), 8)
+
+	setColor := func(c chroma.Colour) {
+		pdf.SetTextColor(int(c.Red()), int(c.Green()), int(c.Blue()))
+	}
+	cell := func(x, y, width float64, text, align string) {
+		pdf.SetXY(x, y)
+		pdf.CellFormat(width, lineHeight, text, "", 0, align, false, 0, "")
+	}
+	pdf.Ln(1)
+	y := pdf.GetY()
+	// newRow fills the background of a row, first moving to a new page
+	// if it does not fit.
+	newRow := func() {
+		if y+lineHeight > pageHeight-bottom {
+			pdf.AddPage()
+			y = pdf.GetY()
 		}
+		pdf.SetFillColor(int(background.Red()), int(background.Green()), int(background.Blue()))
+		pdf.Rect(left, y, pageWidth-left-right, lineHeight, "F")
+	}
 
-		// Headings.
-		if strings.HasPrefix(line, "#") {
-			level := 0
-			for _, ch := range line {
-				if ch == '#' {
-					level++
-				} else {
-					break
+	for i, line := range lines {
+		newRow()
+		if gutter > 0 {
+			pdf.SetTextColor(150, 150, 150)
+			cell(left+pad, y, gutter-charWidth, strconv.Itoa(i+1), "R")
+		}
+		x, col := textX, 0
+		for _, span := range line {
+			text := []rune(pdfText(strings.ReplaceAll(span.text, "\t", "    ")))
+			color := span.color
+			if !color.IsSet() {
+				color = textColor
+			}
+			for len(text) > 0 {
+				if col == cols {
+					y += lineHeight
+					newRow()
+					pdf.SetTextColor(150, 150, 150)
+					cell(textX, y, 2*charWidth, "↪", "L")
+					x, col = textX+2*charWidth, 2
 				}
+				k := min(len(text), cols-col)
+				setColor(color)
+				cell(x, y, float64(k)*charWidth, string(text[:k]), "L")
+				x += float64(k) * charWidth
+				col += k
+				text = text[k:]
 			}
-			text := strings.TrimSpace(strings.TrimLeft(line, "# "))
-			renderHeading(pdf, text, level)
-			continue
 		}
+		y += lineHeight
+	}
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(left, y)
+}
 
-		// List items.
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") {
-			pdf.SetFont("Helvetica", "", 10)
-			text := "• " + strings.TrimSpace(trimmed[2:])
-			text = cleanInlineMarkdown(text)
-			pdf.MultiCell(0, 5, text, "", "L", false)
-			continue
+// list renders list items with a bullet or number hanging in the left
+// margin; nested blocks are indented under the item text.
+func (w *pdfWriter) list(l *ast.List) {
+	pdf := w.pdf
+	left, _, _, _ := pdf.GetMargins()
+	i := 0
+	for item := l.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "•"
+		if l.IsOrdered() {
+			marker = fmt.Sprintf("%d.", l.Start+i)
 		}
+		pdf.SetFont(fontBody, "", w.size)
+		w.textColor()
+		indent := max(pdf.GetStringWidth(marker)+2.5, 6)
 
-		// Numbered list items.
-		if matched, _ := regexp.MatchString(`^\d+\.\s`, trimmed); matched {
-			pdf.SetFont("Helvetica", "", 10)
-			text := cleanInlineMarkdown(trimmed)
-			pdf.MultiCell(0, 5, text, "", "L", false)
-			continue
+		pdf.SetX(left)
+		pdf.CellFormat(indent, w.lineHeight(), marker, "", 0, "L", false, 0, "")
+		pdf.SetLeftMargin(left + indent)
+		if item.FirstChild() == nil {
+			pdf.Ln(w.lineHeight())
 		}
+		w.blocks(item, l.IsTight)
+		pdf.SetLeftMargin(left)
+		pdf.SetX(left)
+		i++
+	}
+}
 
-		// Regular paragraph text.
-		pdf.SetFont("Helvetica", "", 10)
-		text := cleanInlineMarkdown(line)
-		pdf.MultiCell(0, 5, text, "", "L", false)
+// table renders a GFM table with wrapped cells; the header row is repeated
+// after a page break.
+func (w *pdfWriter) table(t *east.Table) {
+	pdf := w.pdf
+	left, _, right, bottom := pdf.GetMargins()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	avail := pageWidth - left - right
+	cols := len(t.Alignments)
+	if cols == 0 {
+		return
 	}
 
-	var buf bytes.Buffer
-	err := pdf.Output(&buf)
-	if err != nil {
-		return nil, err
+	var header []string
+	var rows [][]string
+	natural := make([]float64, cols)
+	for row := t.FirstChild(); row != nil; row = row.NextSibling() {
+		_, isHeader := row.(*east.TableHeader)
+		style := ""
+		if isHeader {
+			style = "B"
+		}
+		pdf.SetFont(fontBody, style, w.scale(pdfCodeSize))
+		cells := make([]string, cols)
+		i := 0
+		for cell := row.FirstChild(); cell != nil && i < cols; cell = cell.NextSibling() {
+			cells[i] = pdfText(nodeText(cell, w.source))
+			natural[i] = max(natural[i], pdf.GetStringWidth(cells[i])+4)
+			i++
+		}
+		if isHeader {
+			header = cells
+		} else {
+			rows = append(rows, cells)
+		}
+	}
+
+	// Columns get their natural width when the table fits, otherwise a
+	// share of the page proportional to it.
+	total := 0.0
+	for _, n := range natural {
+		total += n
+	}
+	widths := make([]float64, cols)
+	for i, n := range natural {
+		if total <= avail {
+			widths[i] = n
+		} else {
+			widths[i] = avail * n / total
+		}
+	}
+	aligns := make([]string, cols)
+	for i, a := range t.Alignments {
+		switch a {
+		case east.AlignRight:
+			aligns[i] = "R"
+		case east.AlignCenter:
+			aligns[i] = "C"
+		default:
+			aligns[i] = "L"
+		}
+	}
+
+	cellLine := w.scale(pdfCodeLine)
+	var drawRow func(cells []string, isHeader bool)
+	drawRow = func(cells []string, isHeader bool) {
+		style := ""
+		if isHeader {
+			style = "B"
+		}
+		pdf.SetFont(fontBody, style, w.scale(pdfCodeSize))
+		lines := make([][]string, cols)
+		height := cellLine
+		for i, cell := range cells {
+			lines[i] = pdf.SplitText(cell, widths[i]-2)
+			height = max(height, float64(len(lines[i]))*cellLine)
+		}
+		height += 2
+
+		if pdf.GetY()+height > pageHeight-bottom {
+			pdf.AddPage()
+			if !isHeader && header != nil {
+				drawRow(header, true)
+				pdf.SetFont(fontBody, style, w.scale(pdfCodeSize))
+			}
+		}
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetDrawColor(190, 190, 190)
+		pdf.SetFillColor(240, 240, 240)
+		x, y := left, pdf.GetY()
+		for i := range cells {
+			fill := "D"
+			if isHeader {
+				fill = "FD"
+			}
+			pdf.Rect(x, y, widths[i], height, fill)
+			for j, line := range lines[i] {
+				pdf.SetXY(x+1, y+1+float64(j)*cellLine)
+				pdf.CellFormat(widths[i]-2, cellLine, line, "", 0, aligns[i], false, 0, "")
+			}
+			x += widths[i]
+		}
+		pdf.SetDrawColor(0, 0, 0)
+		pdf.SetXY(left, y+height)
+	}
+
+	if header != nil {
+		drawRow(header, true)
+	}
+	for _, cells := range rows {
+		drawRow(cells, false)
 	}
-	return buf.Bytes(), nil
 }
 
-// Extension returns the file extension for PDF output.
-func (r *PDFRenderer) Extension() string {
-	return ".pdf"
+// inlineStyle is the formatting of a run of inline text.
+type inlineStyle struct {
+	bold, italic, strike, code bool
+	url                        string // external link target
+	link                       int    // internal gofpdf link, when internal
+	internal                   bool
 }
 
-// renderHeading sets the font size based on heading level and writes text.
-func renderHeading(pdf *gofpdf.Fpdf, text string, level int) {
-	sizes := map[int]float64{1: 18, 2: 15, 3: 13, 4: 12, 5: 11, 6: 10}
-	size, ok := sizes[level]
-	if !ok {
-		size = 10
+// inline writes the inline content of a paragraph, heading-less text
+// block or table cell as flowing text, ending the line.
+func (w *pdfWriter) inline(n ast.Node) {
+	w.inlineChildren(n, inlineStyle{})
+	w.pdf.Ln(w.lineHeight())
+}
+
+func (w *pdfWriter) inlineChildren(n ast.Node, st inlineStyle) {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			// inlineText turns a soft line break into a space.
+			w.run(inlineText(c, w.source, ""), st)
+			if c.HardLineBreak() {
+				w.pdf.Ln(w.lineHeight())
+			}
+		case *ast.String:
+			w.run(string(c.Value), st)
+		case *ast.CodeSpan:
+			s := st
+			s.code = true
+			w.run(inlineText(c, w.source, " "), s)
+		case *ast.Emphasis:
+			s := st
+			if c.Level >= 2 {
+				s.bold = true
+			} else {
+				s.italic = true
+			}
+			w.inlineChildren(c, s)
+		case *east.Strikethrough:
+			s := st
+			s.strike = true
+			w.inlineChildren(c, s)
+		case *ast.Link:
+			s := st
+			dest := string(c.Destination)
+			if id, ok := strings.CutPrefix(dest, "#"); ok {
+				s.link, s.internal = w.anchors[id]
+			} else {
+				s.url = dest
+			}
+			w.inlineChildren(c, s)
+		case *ast.AutoLink:
+			s := st
+			s.url = string(c.URL(w.source))
+			w.run(string(c.Label(w.source)), s)
+		case *east.TaskCheckBox:
+			if c.IsChecked {
+				w.run("☑ ", st)
+			} else {
+				w.run("☐ ", st)
+			}
+		case *ast.Image, *ast.RawHTML:
+			// Not rendered.
+		default:
+			w.inlineChildren(c, st)
+		}
 	}
-	pdf.Ln(4)
-	pdf.SetFont("Helvetica", "B", size)
-	pdf.MultiCell(0, size*0.6, cleanInlineMarkdown(text), "", "L", false)
-	pdf.Ln(2)
 }
 
-// cleanInlineMarkdown strips inline Markdown formatting for PDF rendering.
-func cleanInlineMarkdown(text string) string {
-	// Remove bold markers.
-	text = strings.ReplaceAll(text, "**", "")
-	text = strings.ReplaceAll(text, "__", "")
-	// Remove italic markers (but not inside words like don't).
-	re := regexp.MustCompile(`(?:^|\s)\*([^*]+)\*(?:\s|$)`)
-	text = re.ReplaceAllString(text, " $1 ")
-	// Remove inline code markers.
-	text = regexp.MustCompile("`([^`]+)`").ReplaceAllString(text, "$1")
-	// Remove link syntax, keep text.
-	text = regexp.MustCompile(`\[([^\]]*)\]\([^)]+\)`).ReplaceAllString(text, "$1")
-	return strings.TrimSpace(text)
+// run writes one piece of text in the given style.
+func (w *pdfWriter) run(text string, st inlineStyle) {
+	if text == "" {
+		return
+	}
+	pdf := w.pdf
+	family, style, size := fontBody, "", w.size
+	switch {
+	case st.code:
+		family, size = fontMono, w.scale(pdfCodeSize)
+	case st.bold && st.italic:
+		style = "BI"
+	case st.bold:
+		style = "B"
+	case st.italic:
+		style = "I"
+	}
+	if st.strike {
+		style += "S"
+	}
+	isLink := st.url != "" || st.internal
+	if isLink {
+		style += "U"
+	}
+	pdf.SetFont(family, style, size)
+
+	switch {
+	case isLink:
+		pdf.SetTextColor(30, 80, 160)
+	case st.code:
+		pdf.SetTextColor(150, 30, 30)
+	default:
+		w.textColor()
+	}
+
+	text = pdfText(text)
+	switch {
+	case st.internal:
+		pdf.WriteLinkID(w.lineHeight(), text, st.link)
+	case st.url != "":
+		pdf.WriteLinkString(w.lineHeight(), text, st.url)
+	default:
+		pdf.Write(w.lineHeight(), text)
+	}
+	w.textColor()
+}
+
+// textColor sets the color of body text.
+func (w *pdfWriter) textColor() {
+	if w.gray {
+		w.pdf.SetTextColor(90, 90, 90)
+	} else {
+		w.pdf.SetTextColor(0, 0, 0)
+	}
 }
@@ -0,0 +1,134 @@
+// Package render — PDF fonts.
+// PDF output embeds TrueType fonts so any Unicode text in the Basic
+// Multilingual Plane renders correctly. DejaVu Sans Condensed (four
+// styles) and DejaVu Sans Mono are bundled; the body font can be replaced
+// with any .ttf, e.g. a CJK font.
+package render
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+//go:embed fonts/*.ttf
+var bundledFonts embed.FS
+
+// Font family names registered with gofpdf.
+const (
+	fontBody = "body"
+	fontMono = "mono"
+)
+
+// pdfFonts holds TrueType data for each style of the body font and for
+// the monospace font. A missing style falls back to regular.
+type pdfFonts struct {
+	regular, bold, italic, boldItalic, mono []byte
+}
+
+// bundledFont reads one of the bundled fonts.
+func bundledFont(name string) []byte {
+	data, err := bundledFonts.ReadFile("fonts/" + name)
+	if err != nil {
+		panic(fmt.Sprintf("bundled font %s: %v", name, err))
+	}
+	return data
+}
+
+// bundledMonoFont is the bundled monospace font, read once.
+var bundledMonoFont = sync.OnceValue(func() []byte {
+	return bundledFont("DejaVuSansMono.ttf")
+})
+
+// defaultPDFFonts returns the bundled fonts, read once and shared by all
+// renderers; they must not be modified.
+var defaultPDFFonts = sync.OnceValue(func() *pdfFonts {
+	return &pdfFonts{
+		regular:    bundledFont("DejaVuSansCondensed.ttf"),
+		bold:       bundledFont("DejaVuSansCondensed-Bold.ttf"),
+		italic:     bundledFont("DejaVuSansCondensed-Oblique.ttf"),
+		boldItalic: bundledFont("DejaVuSansCondensed-BoldOblique.ttf"),
+		mono:       bundledMonoFont(),
+	}
+})
+
+// loadPDFFonts reads a TrueType body font from path. Bold and italic
+// variants named like "Name-Bold.ttf", "Name-Italic.ttf" (or -Oblique) and
+// "Name-BoldItalic.ttf" (or -BoldOblique) next to it are used when present;
+// the bundled monospace font is kept for code.
+func loadPDFFonts(path string) (*pdfFonts, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".ttf") {
+		return nil, fmt.Errorf("PDF font %s: only TrueType (.ttf) fonts are supported", path)
+	}
+	regular, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading PDF font: %w", err)
+	}
+
+	if !isTrueType(regular) {
+		return nil, fmt.Errorf("PDF font %s: not a TrueType font", path)
+	}
+
+	stem := strings.TrimSuffix(path, filepath.Ext(path))
+	stem = strings.TrimSuffix(stem, "-Regular")
+	variant := func(suffixes ...string) []byte {
+		for _, suffix := range suffixes {
+			if data, err := os.ReadFile(stem + "-" + suffix + filepath.Ext(path)); err == nil && isTrueType(data) {
+				return data
+			}
+		}
+		return nil
+	}
+
+	return &pdfFonts{
+		regular:    regular,
+		bold:       variant("Bold"),
+		italic:     variant("Italic", "Oblique"),
+		boldItalic: variant("BoldItalic", "BoldOblique"),
+		mono:       bundledMonoFont(),
+	}, nil
+}
+
+// isTrueType reports whether data starts like a TrueType font file. gofpdf
+// cannot read CFF-based OpenType fonts or font collections, and only logs
+// (rather than returns) errors for unreadable fonts.
+func isTrueType(data []byte) bool {
+	return bytes.HasPrefix(data, []byte{0, 1, 0, 0}) || bytes.HasPrefix(data, []byte("true"))
+}
+
+// register adds the fonts to pdf. gofpdf parses fonts per document, but
+// the font data itself is shared.
+func (f *pdfFonts) register(pdf *gofpdf.Fpdf) {
+	orRegular := func(data []byte) []byte {
+		if data == nil {
+			return f.regular
+		}
+		return data
+	}
+	pdf.AddUTF8FontFromBytes(fontBody, "", f.regular)
+	pdf.AddUTF8FontFromBytes(fontBody, "B", orRegular(f.bold))
+	pdf.AddUTF8FontFromBytes(fontBody, "I", orRegular(f.italic))
+	pdf.AddUTF8FontFromBytes(fontBody, "BI", orRegular(f.boldItalic))
+	pdf.AddUTF8FontFromBytes(fontMono, "", f.mono)
+}
+
+// pdfText prepares text for the embedded fonts: gofpdf only handles the
+// Basic Multilingual Plane, so other characters (most emoji) become U+FFFD,
+// and tabs become spaces.
+func pdfText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r > 0xFFFF:
+			return '�'
+		case r == '\t':
+			return ' '
+		}
+		return r
+	}, s)
+}
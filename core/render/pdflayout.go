@@ -0,0 +1,119 @@
+// Package render — PDF page layout.
+// Page size, orientation, margins and base font size of PDF output, plus
+// the optional running elements: a page-number footer, a header repeating
+// the page title, a table of contents and the bookmark outline.
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Named page sizes accepted by PDFLayout.PageSize, in mm (portrait).
+var pdfPageSizes = map[string][2]float64{
+	"a3":     {297, 420},
+	"a4":     {210, 297},
+	"a5":     {148, 210},
+	"letter": {215.9, 279.4},
+	"legal":  {215.9, 355.6},
+}
+
+// Limits of PDFLayout.FontSize, in points.
+const (
+	MinPDFFontSize = 6.0
+	MaxPDFFontSize = 24.0
+)
+
+// tocDepth is the deepest heading level listed in the table of contents.
+const tocDepth = 3
+
+// PDFLayout controls page geometry and running elements of PDF output.
+type PDFLayout struct {
+	// PageSize is A3, A4, A5, Letter, Legal (any case) or a custom
+	// "WIDTHxHEIGHT" in mm, e.g. "160x240".
+	PageSize  string
+	Landscape bool
+	// Margins are top, right, bottom and left, in mm.
+	Margins  [4]float64
+	FontSize float64 // body text size in points; other sizes scale with it
+
+	PageNumbers bool // "Page N of M" footer
+	Header      bool // page title at the top of every page but the first
+	TOC         bool // table of contents after the title block
+	Outline     bool // PDF bookmarks built from headings
+}
+
+// DefaultPDFLayout returns A4 portrait with 10 mm margins (15 mm at the
+// bottom), 10 pt body text and a bookmark outline.
+func DefaultPDFLayout() PDFLayout {
+	return PDFLayout{
+		PageSize: "A4",
+		Margins:  [4]float64{10, 10, 15, 10},
+		FontSize: 10,
+		Outline:  true,
+	}
+}
+
+// ParsePDFMargins parses margins in mm given as one value for all sides or
+// as four comma-separated values in CSS order: top, right, bottom, left.
+func ParsePDFMargins(s string) ([4]float64, error) {
+	var margins [4]float64
+	parts := strings.Split(s, ",")
+	if len(parts) != 1 && len(parts) != 4 {
+		return margins, fmt.Errorf("margins %q: want one value or four (top,right,bottom,left)", s)
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || v < 0 {
+			return margins, fmt.Errorf("margins %q: %q is not a non-negative number of mm", s, strings.TrimSpace(part))
+		}
+		margins[i] = v
+	}
+	if len(parts) == 1 {
+		margins = [4]float64{margins[0], margins[0], margins[0], margins[0]}
+	}
+	return margins, nil
+}
+
+// pageSize returns the portrait page width and height in mm.
+func (l PDFLayout) pageSize() (width, height float64, err error) {
+	size, ok := pdfPageSizes[strings.ToLower(l.PageSize)]
+	if !ok {
+		w, h, found := strings.Cut(strings.ToLower(l.PageSize), "x")
+		width, errW := strconv.ParseFloat(strings.TrimSpace(w), 64)
+		height, errH := strconv.ParseFloat(strings.TrimSpace(h), 64)
+		if !found || errW != nil || errH != nil || width < 50 || height < 50 {
+			return 0, 0, fmt.Errorf("page size %q: want A3, A4, A5, Letter, Legal or WIDTHxHEIGHT in mm (at least 50x50)", l.PageSize)
+		}
+		size = [2]float64{width, height}
+	}
+	return size[0], size[1], nil
+}
+
+// Validate reports whether the layout can be rendered: a known or
+// well-formed page size, margins that leave room for text, a font size
+// within limits, and enough margin for the header and footer.
+func (l PDFLayout) Validate() error {
+	width, height, err := l.pageSize()
+	if err != nil {
+		return err
+	}
+	if l.Landscape {
+		width, height = height, width
+	}
+	top, right, bottom, left := l.Margins[0], l.Margins[1], l.Margins[2], l.Margins[3]
+	if width-left-right < 40 || height-top-bottom < 40 {
+		return fmt.Errorf("margins leave less than 40 mm for text on a %gx%g mm page", width, height)
+	}
+	if l.FontSize < MinPDFFontSize || l.FontSize > MaxPDFFontSize {
+		return fmt.Errorf("font size %g pt is outside %g-%g", l.FontSize, MinPDFFontSize, MaxPDFFontSize)
+	}
+	if l.Header && top < 10 {
+		return fmt.Errorf("a running header needs a top margin of at least 10 mm (got %g)", top)
+	}
+	if l.PageNumbers && bottom < 10 {
+		return fmt.Errorf("page numbers need a bottom margin of at least 10 mm (got %g)", bottom)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Package render — plain-text renderer.
+// Renders Markdown as readable plain text with the conversion in
+// mdtext.go, wrapped at a configurable width.
+package render
+
+import (
+	"strings"
+
+	"github.com/gaurav-prasanna/pagepipe/core"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// DefaultTextWidth is the default line width of plain-text output.
+const DefaultTextWidth = 80
+
+// TextRenderer renders Markdown content as plain text.
+type TextRenderer struct {
+	// Width is the maximum line length in characters; 0 disables wrapping.
+	// Code blocks and tables are never wrapped.
+	Width int
+
+	md goldmark.Markdown
+}
+
+// NewTextRenderer creates a TextRenderer wrapping lines at width.
+func NewTextRenderer(width int) *TextRenderer {
+	return &TextRenderer{
+		Width: width,
+		md:    goldmark.New(goldmark.WithExtensions(extension.GFM)),
+	}
+}
+
+// Render converts Markdown into plain-text bytes.
+func (r *TextRenderer) Render(markdown string, meta core.PageMetadata) ([]byte, error) {
+	return []byte(r.plain(markdown) + "\n"), nil
+}
+
+// Extension returns the file extension for plain-text output.
+func (r *TextRenderer) Extension() string {
+	return ".txt"
+}
+
+// plain converts Markdown to plain text without a trailing newline.
+func (r *TextRenderer) plain(markdown string) string {
+	source := []byte(markdown)
+	doc := r.md.Parser().Parse(text.NewReader(source))
+	lines := textBlocks(doc, source, r.Width, true)
+	return strings.Join(lines, "\n")
+}
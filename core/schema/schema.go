@@ -0,0 +1,157 @@
+// Package schema — JSON Schema for the JSON output format.
+// The schema is generated from the Go types by reflection, so it cannot
+// drift from what the JSON renderer emits: every exported field becomes a
+// property named by its json tag, fields without omitempty are required,
+// and unknown properties are rejected.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gaurav-prasanna/pagepipe/core"
+)
+
+// Draft is the JSON Schema dialect of generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document or subschema, limited to the keywords
+// Generate emits and Validate understands.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+
+	// closed marks an object that allows no other properties. It is
+	// written as "additionalProperties": false.
+	closed bool
+}
+
+// MarshalJSON writes closed objects with "additionalProperties": false.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if !s.closed {
+		return json.Marshal((*plain)(s))
+	}
+	return json.Marshal(struct {
+		*plain
+		AdditionalProperties bool `json:"additionalProperties"`
+	}{(*plain)(s), false})
+}
+
+// PageJSON returns the schema of core.PageJSON for the current
+// core.JSONSchemaVersion.
+func PageJSON() *Schema {
+	s := Generate(core.PageJSON{})
+	s.ID = fmt.Sprintf("urn:pagepipe:schema:page-json:v%d", core.JSONSchemaVersion)
+	s.Title = "PagePipe page JSON"
+	s.Description = "Output of `pagepipe convert --json` for one page."
+	root := s.Defs["PageJSON"]
+	root.Properties["schema_version"] = &Schema{Type: "integer", Const: core.JSONSchemaVersion}
+	return s
+}
+
+// Generate returns a schema for the type of v. Named struct types are
+// placed in $defs and referenced, and the root refers to v's type.
+func Generate(v any) *Schema {
+	g := &generator{defs: make(map[string]*Schema)}
+	root := g.schemaFor(reflect.TypeOf(v))
+	root.Schema = Draft
+	root.Defs = g.defs
+	return root
+}
+
+type generator struct {
+	defs map[string]*Schema
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{} // arbitrary JSON
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &Schema{Type: "string", Format: "byte"} // base64
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // placeholder for recursive types
+			g.defs[t.Name()] = g.object(t)
+		}
+		return &Schema{Ref: "#/$defs/" + t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.object(t)
+	default:
+		// Interfaces hold arbitrary JSON.
+		return &Schema{}
+	}
+}
+
+// object builds the schema of a struct from its exported fields.
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), closed: true}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			// Embedded struct fields are promoted, as encoding/json does.
+			embedded := g.object(f.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schemaFor(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
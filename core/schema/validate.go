@@ -0,0 +1,141 @@
+// Package schema — validation of JSON documents against a Schema.
+// Only the keywords Generate emits are checked: $ref into $defs, type,
+// const, properties, required, additionalProperties and items.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ValidationError describes one place where a document breaks the schema.
+type ValidationError struct {
+	Path    string // JSON Pointer to the offending value
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// Validate checks the JSON document data against s and returns every
+// violation found, or an error if data is not valid JSON.
+func Validate(s *Schema, data []byte) ([]ValidationError, error) {
+	var doc any
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+	v := &validator{defs: s.Defs}
+	v.check(s, doc, "")
+	return v.errs, nil
+}
+
+type validator struct {
+	defs map[string]*Schema
+	errs []ValidationError
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) check(s *Schema, value any, path string) {
+	if s.Ref != "" {
+		def, ok := v.defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok || def == nil {
+			v.fail(path, "unresolvable $ref %q", s.Ref)
+			return
+		}
+		v.check(def, value, path)
+	}
+
+	if s.Type != "" && jsonType(value) != s.Type && !(s.Type == "number" && jsonType(value) == "integer") {
+		v.fail(path, "expected %s, got %s", s.Type, jsonType(value))
+		return
+	}
+	if s.Const != nil && !equalJSON(s.Const, value) {
+		v.fail(path, "expected %v, got %v", s.Const, value)
+	}
+
+	switch val := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				v.fail(path, "missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := path + "/" + escapePointer(name)
+			switch prop, ok := s.Properties[name]; {
+			case ok:
+				v.check(prop, val[name], child)
+			case s.AdditionalProperties != nil:
+				v.check(s.AdditionalProperties, val[name], child)
+			case s.closed:
+				v.fail(child, "unexpected property")
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range val {
+				v.check(s.Items, item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	}
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// equalJSON compares a Go value with a decoded JSON value by their JSON
+// encodings.
+func equalJSON(want, got any) bool {
+	a, errA := json.Marshal(want)
+	b, errB := json.Marshal(got)
+	if errA != nil || errB != nil {
+		return false
+	}
+	var x, y any
+	_ = json.Unmarshal(a, &x)
+	_ = json.Unmarshal(b, &y)
+	return reflect.DeepEqual(x, y)
+}
+
+// escapePointer escapes a property name for use in a JSON Pointer.
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
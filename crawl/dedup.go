@@ -0,0 +1,127 @@
+// Package crawl — duplicate content detection.
+// Tracks which logical pages have already been ingested, by canonical URL,
+// by exact content hash, and by SimHash for near-duplicates (the same page
+// with a different timestamp, session banner or tracking snippet).
+package crawl
+
+import (
+	"crypto/sha256"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// DefaultSimHashDistance is the maximum Hamming distance between two 64-bit
+// SimHashes for the pages to be treated as near-duplicates.
+const DefaultSimHashDistance = 3
+
+// simHashShingle is the number of consecutive words hashed as one feature.
+const simHashShingle = 3
+
+// Deduper remembers pages already accepted during a crawl.
+type Deduper struct {
+	// MaxDistance is the SimHash distance threshold; negative disables
+	// near-duplicate detection (exact duplicates are still caught).
+	MaxDistance int
+
+	byURL  map[string]bool
+	byHash map[[sha256.Size]byte]string
+	hashes []simEntry
+}
+
+type simEntry struct {
+	hash uint64
+	url  string
+}
+
+// NewDeduper creates a Deduper with the given SimHash distance threshold.
+func NewDeduper(maxDistance int) *Deduper {
+	return &Deduper{
+		MaxDistance: maxDistance,
+		byURL:       make(map[string]bool),
+		byHash:      make(map[[sha256.Size]byte]string),
+	}
+}
+
+// Add records a page under its canonical URL unless its content duplicates
+// an earlier page. It returns the URL of the earlier page and true when the
+// page is a duplicate, in which case nothing is recorded.
+func (d *Deduper) Add(canonical string, content string) (string, bool) {
+	if d.byURL[canonical] {
+		return canonical, true
+	}
+
+	normalized := normalizeContent(content)
+	sum := sha256.Sum256([]byte(normalized))
+	if prev, ok := d.byHash[sum]; ok {
+		return prev, true
+	}
+
+	hash := SimHash(normalized)
+	if d.MaxDistance >= 0 && normalized != "" {
+		for _, e := range d.hashes {
+			if bits.OnesCount64(e.hash^hash) <= d.MaxDistance {
+				return e.url, true
+			}
+		}
+	}
+
+	d.byURL[canonical] = true
+	d.byHash[sum] = canonical
+	d.hashes = append(d.hashes, simEntry{hash: hash, url: canonical})
+	return "", false
+}
+
+// SimHash computes a 64-bit SimHash over word shingles of text.
+// Similar texts produce hashes with a small Hamming distance.
+func SimHash(text string) uint64 {
+	words := strings.Fields(normalizeContent(text))
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	addFeature := func(feature string) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	if len(words) < simHashShingle {
+		addFeature(strings.Join(words, " "))
+	}
+	for i := 0; i+simHashShingle <= len(words); i++ {
+		addFeature(strings.Join(words[i:i+simHashShingle], " "))
+	}
+
+	var hash uint64
+	for i, w := range weights {
+		if w > 0 {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// normalizeContent lowercases text, drops punctuation and collapses
+// whitespace so formatting differences don't defeat the hashes.
+func normalizeContent(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -15,6 +15,14 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gaurav-prasanna/pagepipe/core"
+	"github.com/gaurav-prasanna/pagepipe/core/fetch"
+	"github.com/gaurav-prasanna/pagepipe/core/httpclient"
+)
+
+const (
+	// maxSitemapSize is the largest sitemap.xml body read.
+	maxSitemapSize = 50 << 20
+	sitemapTimeout = 15 * time.Second
 )
 
 // sitemapURL holds a URL from a sitemap.xml.
@@ -27,34 +35,83 @@ type sitemapIndex struct {
 	URLs []sitemapURL `xml:"url"`
 }
 
+// Options configures URL discovery.
+type Options struct {
+	// Normalizer canonicalises discovered URLs for deduplication.
+	// Defaults to NewURLNormalizer() when nil.
+	Normalizer *URLNormalizer
+	// Auth supplies cookies and credentials for the sitemap request.
+	Auth *fetch.Auth
+	// Transport carries the sitemap and robots.txt requests; nil uses
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// UserAgent identifies the crawler on sitemap and robots.txt requests,
+	// and its product token selects the robots.txt group.
+	// Defaults to httpclient.DefaultUserAgent when empty.
+	UserAgent string
+	// IgnoreRobots disables robots.txt checks.
+	IgnoreRobots bool
+	// OnWarning, if set, is told about problems that do not stop
+	// discovery, such as an unreachable robots.txt.
+	OnWarning func(msg string)
+}
+
+// warnf reports a non-fatal problem through OnWarning.
+func (o Options) warnf(format string, args ...any) {
+	if o.OnWarning != nil {
+		o.OnWarning(fmt.Sprintf(format, args...))
+	}
+}
+
 // DiscoverAll finds all internal URLs to process starting from baseURL.
 // It first tries sitemap.xml, then falls back to link crawling.
-// The baseURL itself is always included.
-func DiscoverAll(ctx context.Context, baseURL string, fetcher core.Fetcher) ([]string, error) {
+// URLs disallowed by robots.txt are skipped, except baseURL itself,
+// which link crawling always includes.
+func DiscoverAll(ctx context.Context, baseURL string, fetcher core.Fetcher, opts Options) ([]string, error) {
+	if opts.Normalizer == nil {
+		opts.Normalizer = NewURLNormalizer()
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = httpclient.DefaultUserAgent
+	}
+
 	parsed, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("parsing base URL: %w", err)
 	}
 	domain := parsed.Host
 
+	client := httpclient.Client(opts.Transport, sitemapTimeout)
+	opts.Auth.Configure(client)
+
+	var robots *Robots
+	if !opts.IgnoreRobots {
+		robots, err = fetchRobots(ctx, client, parsed, opts)
+		if err != nil {
+			opts.warnf("robots.txt could not be fetched, crawling without it: %v", err)
+			robots = nil
+		}
+	}
+
 	// Try sitemap first.
 	sitemapURLStr := fmt.Sprintf("%s://%s/sitemap.xml", parsed.Scheme, domain)
-	urls, err := discoverFromSitemap(ctx, sitemapURLStr, domain)
+	urls, err := discoverFromSitemap(ctx, client, sitemapURLStr, domain, opts, robots)
 	if err == nil && len(urls) > 0 {
 		return urls, nil
 	}
 
 	// Fall back to BFS link crawling.
-	return discoverFromLinks(ctx, baseURL, domain, fetcher)
+	return discoverFromLinks(ctx, baseURL, domain, fetcher, opts.Normalizer, robots)
 }
 
 // discoverFromSitemap fetches and parses sitemap.xml for internal URLs.
-func discoverFromSitemap(ctx context.Context, sitemapURL string, domain string) ([]string, error) {
-	client := &http.Client{Timeout: 15 * time.Second}
+func discoverFromSitemap(ctx context.Context, client *http.Client, sitemapURL string, domain string, opts Options, robots *Robots) ([]string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+	opts.Auth.Apply(req)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -66,7 +123,8 @@ func discoverFromSitemap(ctx context.Context, sitemapURL string, domain string)
 		return nil, fmt.Errorf("sitemap returned %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	// The sitemap protocol caps files at 50 MB uncompressed.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSitemapSize))
 	if err != nil {
 		return nil, err
 	}
@@ -76,39 +134,62 @@ func discoverFromSitemap(ctx context.Context, sitemapURL string, domain string)
 		return nil, err
 	}
 
-	var urls []string
+	// Sitemaps often list the same page under several spellings.
+	queue := NewQueue()
 	for _, u := range sitemap.URLs {
-		if IsSameDomain(u.Loc, domain) && !IsStaticAsset(u.Loc) {
-			urls = append(urls, NormalizeURL(u.Loc))
+		loc := strings.TrimSpace(u.Loc)
+		if IsSameDomain(loc, domain) && !IsStaticAsset(loc) && robots.Allowed(loc) {
+			queue.Add(opts.Normalizer.Normalize(loc))
 		}
 	}
-	return urls, nil
+	return queue.All(), nil
 }
 
 // discoverFromLinks performs BFS crawling to find internal links.
-func discoverFromLinks(ctx context.Context, startURL string, domain string, fetcher core.Fetcher) ([]string, error) {
+// Pages declaring a <link rel="canonical"> are recorded under that URL.
+func discoverFromLinks(ctx context.Context, startURL string, domain string, fetcher core.Fetcher, norm *URLNormalizer, robots *Robots) ([]string, error) {
 	queue := NewQueue()
-	queue.Add(NormalizeURL(startURL))
+	queue.Add(norm.Normalize(startURL))
 
 	// BFS with a reasonable limit to avoid runaway crawls.
 	const maxPages = 100
 
 	for queue.HasNext() && queue.Visited() < maxPages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		currentURL := queue.Next()
 
 		result, err := fetcher.Fetch(ctx, currentURL)
 		if err != nil {
 			continue // Skip failed pages, don't block the crawl.
 		}
+		if result.Kind != core.ContentHTML {
+			continue // Still converted, but only HTML is searched for links.
+		}
 
-		links, err := extractLinks(result.HTML, currentURL)
+		// Resolve links against the final URL, since a redirect may have
+		// changed the directory relative links are based on.
+		pageURL := currentURL
+		if result.FinalURL != "" && IsSameDomain(result.FinalURL, domain) {
+			pageURL = result.FinalURL
+		}
+
+		links, canonical, err := extractLinks(result.HTML, pageURL)
 		if err != nil {
 			continue
 		}
 
+		switch {
+		case canonical != "" && IsSameDomain(canonical, domain):
+			queue.SetCanonical(currentURL, norm.Normalize(canonical))
+		case pageURL != currentURL:
+			queue.SetCanonical(currentURL, norm.Normalize(pageURL))
+		}
+
 		for _, link := range links {
-			if IsSameDomain(link, domain) && !IsStaticAsset(link) {
-				queue.Add(NormalizeURL(link))
+			if IsSameDomain(link, domain) && !IsStaticAsset(link) && robots.Allowed(link) {
+				queue.Add(norm.Normalize(link))
 			}
 		}
 	}
@@ -117,10 +198,11 @@ func discoverFromLinks(ctx context.Context, startURL string, domain string, fetc
 }
 
 // extractLinks extracts all href values from <a> tags, resolving relative URLs.
-func extractLinks(html string, baseURL string) ([]string, error) {
+// It also returns the resolved <link rel="canonical"> href, if any.
+func extractLinks(html string, baseURL string) ([]string, string, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	base, _ := url.Parse(baseURL)
@@ -138,7 +220,13 @@ func extractLinks(html string, baseURL string) ([]string, error) {
 		}
 	})
 
-	return links, nil
+	var canonical string
+	doc.Find(`link[rel~="canonical"][href]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		canonical = resolveURL(strings.TrimSpace(s.AttrOr("href", "")), base)
+		return canonical == ""
+	})
+
+	return links, canonical, nil
 }
 
 // resolveURL resolves a potentially relative URL against a base.
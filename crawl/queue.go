@@ -6,13 +6,15 @@ package crawl
 type Queue struct {
 	items   []string
 	visited map[string]bool
-	idx     int // current read position
+	dropped map[string]string // item → the other item it declares canonical
+	idx     int               // current read position
 }
 
 // NewQueue creates an empty Queue.
 func NewQueue() *Queue {
 	return &Queue{
 		visited: make(map[string]bool),
+		dropped: make(map[string]string),
 	}
 }
 
@@ -42,7 +44,59 @@ func (q *Queue) Visited() int {
 	return len(q.visited)
 }
 
-// All returns all discovered URLs (in BFS order).
+// SetCanonical records that url declares canonical as its canonical URL.
+// The item is reported under the canonical URL by All, or left out if the
+// canonical URL is another item that All reports; canonical is never
+// enqueued afterwards. A page whose canonical URL was only seen as an alias,
+// or belongs to an item that is itself left out, is kept under url so its
+// content is not lost.
+func (q *Queue) SetCanonical(url, canonical string) {
+	if url == canonical {
+		return
+	}
+	i := q.indexOf(url)
+	if i < 0 {
+		return
+	}
+	switch {
+	case q.indexOf(canonical) >= 0:
+		q.dropped[url] = canonical
+	case q.visited[canonical]:
+		// Seen only as an alias of another page: keep url.
+	default:
+		q.visited[canonical] = true
+		q.items[i] = canonical
+		// Keep the alias in the visited set so it is not re-added.
+		q.visited[url] = true
+	}
+}
+
+// indexOf returns the position of item in the queue, or -1.
+func (q *Queue) indexOf(item string) int {
+	for i, it := range q.items {
+		if it == item {
+			return i
+		}
+	}
+	return -1
+}
+
+// All returns all discovered URLs (in BFS order). An item deferring to its
+// canonical URL is left out only when that URL is reported itself.
 func (q *Queue) All() []string {
-	return q.items
+	if len(q.dropped) == 0 {
+		return q.items
+	}
+	queued := make(map[string]bool, len(q.items))
+	for _, item := range q.items {
+		queued[item] = true
+	}
+	out := make([]string, 0, len(q.items))
+	for _, item := range q.items {
+		if canonical, ok := q.dropped[item]; ok && queued[canonical] && q.dropped[canonical] == "" {
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
 }
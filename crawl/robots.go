@@ -0,0 +1,188 @@
+// Package crawl — robots.txt rules.
+// Fetches and evaluates robots.txt (RFC 9309) so discovery skips paths the
+// site has disallowed for our user agent.
+package crawl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxRobotsSize is the largest robots.txt body parsed (RFC 9309 requires
+// at least 500 KiB be honoured).
+const maxRobotsSize = 512 << 10
+
+// robotsRule is a single Allow or Disallow line.
+type robotsRule struct {
+	allow   bool
+	length  int            // pattern length, for longest-match precedence
+	pattern *regexp.Regexp // "*" wildcards and "$" anchor compiled
+}
+
+// Robots holds the robots.txt rules that apply to one user agent.
+// A nil *Robots allows everything.
+type Robots struct {
+	rules []robotsRule
+}
+
+// fetchRobots downloads and parses robots.txt for the site of base.
+// Per RFC 9309, a missing file (4xx) allows everything; a server error
+// disallows everything, since the site may be unavailable. Rules that
+// block every page are reported through opts.OnWarning, as discovery then
+// yields only the start URL.
+func fetchRobots(ctx context.Context, client *http.Client, base *url.URL, opts Options) (*Robots, error) {
+	robotsURL := fmt.Sprintf("%s://%s/robots.txt", base.Scheme, base.Host)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+	opts.Auth.Apply(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	token := ProductToken(opts.UserAgent)
+	if resp.StatusCode >= 500 {
+		opts.warnf("robots.txt returned %s, so every page is treated as disallowed and only the start URL is converted (--ignore_robots overrides)", resp.Status)
+		return &Robots{rules: []robotsRule{newRobotsRule(false, "/")}}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+
+	robots := ParseRobots(io.LimitReader(resp.Body, maxRobotsSize), token)
+	if robots.DisallowsAll() {
+		opts.warnf("robots.txt disallows every page for %s, so only the start URL is converted (--ignore_robots overrides)", token)
+	}
+	return robots, nil
+}
+
+// DisallowsAll reports whether the rules block the whole site: the root
+// is disallowed and no Allow rule opens any part of it again.
+func (r *Robots) DisallowsAll() bool {
+	if r == nil || r.Allowed("/") {
+		return false
+	}
+	for _, rule := range r.rules {
+		if rule.allow {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRobots parses a robots.txt file and keeps the rules of the group
+// matching token (compared case-insensitively), falling back to the "*"
+// group. Rules of all groups matching the token are merged.
+func ParseRobots(r io.Reader, token string) *Robots {
+	token = strings.ToLower(token)
+
+	var specific, wildcard []robotsRule
+	var agents []string
+	hasSpecific := false
+	inRules := false // a rule line ends the current user-agent list
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i != -1 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agent := strings.ToLower(ProductToken(value))
+			agents = append(agents, agent)
+			hasSpecific = hasSpecific || agent == token
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue // "Disallow:" with no path allows everything
+			}
+			rule := newRobotsRule(key == "allow", value)
+			for _, agent := range agents {
+				switch agent {
+				case token:
+					specific = append(specific, rule)
+				case "*":
+					wildcard = append(wildcard, rule)
+				}
+			}
+		}
+	}
+
+	if hasSpecific {
+		return &Robots{rules: specific}
+	}
+	return &Robots{rules: wildcard}
+}
+
+// newRobotsRule compiles a robots.txt path pattern, where "*" matches any
+// sequence and a trailing "$" anchors the end.
+func newRobotsRule(allow bool, pattern string) robotsRule {
+	anchored := strings.HasSuffix(pattern, "$")
+	expr := regexp.QuoteMeta(strings.TrimSuffix(pattern, "$"))
+	expr = "^" + strings.ReplaceAll(expr, `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return robotsRule{allow: allow, length: len(pattern), pattern: regexp.MustCompile(expr)}
+}
+
+// Allowed reports whether the URL's path may be crawled. The longest
+// matching rule wins; on a tie Allow wins.
+func (r *Robots) Allowed(rawURL string) bool {
+	if r == nil {
+		return true
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+	target := parsed.EscapedPath()
+	if target == "" {
+		target = "/"
+	}
+	if parsed.RawQuery != "" {
+		target += "?" + parsed.RawQuery
+	}
+
+	allowed, best := true, -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(target) {
+			continue
+		}
+		n := rule.length
+		if n > best || (n == best && rule.allow) {
+			allowed, best = rule.allow, n
+		}
+	}
+	return allowed
+}
+
+// ProductToken returns the product name of a User-Agent string, which is
+// what robots.txt groups are matched against: "PagePipe/1.0 (...)" → "PagePipe".
+func ProductToken(userAgent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(userAgent), " ")
+	token, _, _ = strings.Cut(token, "/")
+	return token
+}
@@ -25,7 +25,7 @@ func IsSameDomain(rawURL string, domain string) bool {
 	if err != nil {
 		return false
 	}
-	return parsed.Host == domain
+	return strings.EqualFold(parsed.Host, domain)
 }
 
 // IsStaticAsset checks if a URL points to a static asset (image, CSS, JS, etc.).
@@ -38,8 +38,50 @@ func IsStaticAsset(rawURL string) bool {
 	return staticExtensions[ext]
 }
 
-// NormalizeURL strips fragments and trailing slashes for deduplication.
+// DefaultStripParams are query parameters that never change page content:
+// analytics tags, click identifiers and session IDs.
+var DefaultStripParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "msclkid", "mc_cid", "mc_eid", "_ga", "_gl",
+	"ref_src",
+	"sid", "sessionid", "session_id", "phpsessid", "jsessionid", "aspsessionid*", "cfid", "cftoken",
+}
+
+// indexFiles are directory index documents that resolve to their directory.
+var indexFiles = map[string]bool{
+	"index.html": true, "index.htm": true, "index.php": true,
+	"default.htm": true, "default.html": true, "default.aspx": true,
+}
+
+// URLNormalizer canonicalises URLs so the same logical page reached through
+// different spellings maps to one key.
+type URLNormalizer struct {
+	// StripParams lists query parameters to drop (case-insensitive).
+	// A trailing "*" matches by prefix, e.g. "utm_*".
+	StripParams []string
+}
+
+// NewURLNormalizer creates a URLNormalizer that strips DefaultStripParams
+// plus any extra parameters given.
+func NewURLNormalizer(extraParams ...string) *URLNormalizer {
+	params := append([]string{}, DefaultStripParams...)
+	params = append(params, extraParams...)
+	return &URLNormalizer{StripParams: params}
+}
+
+// defaultNormalizer backs the package-level NormalizeURL.
+var defaultNormalizer = NewURLNormalizer()
+
+// NormalizeURL normalizes rawURL with the default parameter list.
 func NormalizeURL(rawURL string) string {
+	return defaultNormalizer.Normalize(rawURL)
+}
+
+// Normalize strips fragments, trailing slashes, directory index files,
+// default ports, ignored query parameters and path-parameter session IDs
+// (;jsessionid=...). Scheme and host are lowercased and the remaining
+// query parameters are sorted. Path case is preserved since servers may
+// treat it as significant.
+func (n *URLNormalizer) Normalize(rawURL string) string {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return rawURL
@@ -47,11 +89,65 @@ func NormalizeURL(rawURL string) string {
 
 	// Remove fragment.
 	parsed.Fragment = ""
+	parsed.RawFragment = ""
+
+	// Lowercase scheme and host, drop default ports.
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	parsed.Host = host
+
+	// Drop ;jsessionid=... style path parameters.
+	if i := strings.Index(parsed.Path, ";"); i != -1 {
+		parsed.Path = parsed.Path[:i]
+		parsed.RawPath = ""
+	}
+
+	// Collapse /dir/index.html to /dir.
+	if indexFiles[strings.ToLower(path.Base(parsed.Path))] {
+		parsed.Path = strings.TrimSuffix(parsed.Path, path.Base(parsed.Path))
+		parsed.RawPath = ""
+	}
 
 	// Remove trailing slash (but keep root "/").
 	if parsed.Path != "/" {
 		parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+		parsed.RawPath = strings.TrimSuffix(parsed.RawPath, "/")
 	}
 
+	// Drop ignored parameters; url.Values.Encode sorts the rest by key.
+	if parsed.RawQuery != "" {
+		query := parsed.Query()
+		for key := range query {
+			if n.stripped(key) {
+				query.Del(key)
+			}
+		}
+		parsed.RawQuery = query.Encode()
+	}
+	parsed.ForceQuery = false
+
 	return parsed.String()
 }
+
+// stripped reports whether the query parameter key should be removed.
+func (n *URLNormalizer) stripped(key string) bool {
+	key = strings.ToLower(key)
+	for _, p := range n.StripParams {
+		p = strings.ToLower(p)
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,8 @@ package main
 
 import "github.com/gaurav-prasanna/pagepipe/cmd"
 
+//go:generate go run . schema --output schema/page.schema.json
+
 func main() {
 	cmd.Execute()
 }